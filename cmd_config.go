@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func configShowMain() {
+	devMessage("Entering configShowMain()")
+	sanitizeArguments()
+	resolveDatabaseFile(false)
+
+	content, contentErr := yaml.Marshal(config)
+	if contentErr != nil {
+		stdErr.Printf("Error encoding configuration: %s\n", contentErr)
+		os.Exit(errConfigFile)
+	}
+	if config.ConfigFile != "" {
+		fmt.Printf("# Configuration file: %s\n", config.ConfigFile)
+	} else {
+		fmt.Printf("# No configuration file\n")
+	}
+	fmt.Print(string(content))
+
+	devMessage("Leaving configShowMain()")
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
+)
+
+func diffMain(args []string) {
+	devMessage("Entering diffMain()")
+	sanitizeArguments()
+
+	if config.Output.ReportFormat != "text" && config.Output.ReportFormat != "json" && config.Output.ReportFormat != "yaml" {
+		stdErr.Printf("Error: Unsupported output format %s, use text, json or yaml\n", config.Output.ReportFormat)
+		os.Exit(errOutputFormat)
+	}
+
+	oldDB, oldDBErr := parseDatabaseFile(args[0])
+	if oldDBErr != nil {
+		stdErr.Printf("Error loading database: %s\n", oldDBErr)
+		os.Exit(errDatabaseLoad)
+	}
+	newDB, newDBErr := parseDatabaseFile(args[1])
+	if newDBErr != nil {
+		stdErr.Printf("Error loading database: %s\n", newDBErr)
+		os.Exit(errDatabaseLoad)
+	}
+	diff := oui.Diff(oldDB, newDB)
+
+	switch config.Output.ReportFormat {
+	case "json":
+		content, _ := json.MarshalIndent(diff, "", "    ")
+		fmt.Println(string(content))
+	case "yaml":
+		content, _ := yaml.Marshal(diff)
+		fmt.Print(string(content))
+	default:
+		fmt.Print(diffText(diff))
+	}
+
+	devMessage("Leaving diffMain()")
+}
+
+// diffText lists added (+), removed (-) and changed (~) prefixes followed by
+// a summary line.
+func diffText(diff oui.DatabaseDiff) string {
+	var text strings.Builder
+
+	for _, change := range diff.Added {
+		fmt.Fprintf(&text, "+ %s %s\n", diffPrefix(change.Prefix), change.New.VendorName)
+	}
+	for _, change := range diff.Removed {
+		fmt.Fprintf(&text, "- %s %s\n", diffPrefix(change.Prefix), change.Old.VendorName)
+	}
+	for _, change := range diff.Changed {
+		vendorName := change.New.VendorName
+		if change.Old.VendorName != change.New.VendorName {
+			vendorName = fmt.Sprintf("%s -> %s", change.Old.VendorName, change.New.VendorName)
+		}
+		if strings.Join(change.Old.VendorAddress, ", ") != strings.Join(change.New.VendorAddress, ", ") {
+			vendorName += " (address changed)"
+		}
+		fmt.Fprintf(&text, "~ %s %s\n", diffPrefix(change.Prefix), vendorName)
+	}
+	fmt.Fprintf(&text, "%d added, %d removed, %d changed\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+
+	return text.String()
+}
+
+func diffPrefix(prefix string) string {
+	formatted, formattedErr := oui.FormatPrefix(prefix)
+	if formattedErr != nil {
+		return prefix
+	}
+	return formatted
+}
@@ -10,6 +10,7 @@ func exportMain() {
 
 	devMessage("Entering exportMain()")
 	sanitizeArguments()
+	resolveDatabaseFile(false)
 
 	db, dbErr := loadDatabase(config.DatabaseFile)
 	if dbErr != nil {
@@ -24,6 +25,16 @@ func exportMain() {
 		output = db.ToCSV()
 	case "json":
 		output = db.ToJSON()
+	case "manuf":
+		output = db.ToManuf()
+	case "nmap":
+		output = db.ToNmap()
+	case "arp-scan":
+		output = db.ToArpScan()
+	case "arp-scan-vendor":
+		output = db.ToArpScanVendor()
+	case "zeek":
+		output = db.ToZeek()
 	default:
 		stdErr.Printf("Error: Unsupported export format.")
 		os.Exit(errExportFormat)
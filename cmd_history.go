@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+func historyListMain() {
+	devMessage("Entering historyListMain()")
+	sanitizeArguments()
+	resolveDatabaseFile(false)
+
+	if config.Output.ReportFormat != "text" && config.Output.ReportFormat != "json" && config.Output.ReportFormat != "yaml" {
+		stdErr.Printf("Error: Unsupported output format %s, use text, json or yaml\n", config.Output.ReportFormat)
+		os.Exit(errOutputFormat)
+	}
+
+	history, historyErr := loadHistory(config.DatabaseFile)
+	if historyErr != nil {
+		stdErr.Printf("Error loading history: %s\n", historyErr)
+		os.Exit(errHistory)
+	}
+
+	switch config.Output.ReportFormat {
+	case "json":
+		if history == nil {
+			history = []historyEntry{}
+		}
+		content, _ := json.MarshalIndent(history, "", "    ")
+		fmt.Println(string(content))
+	case "yaml":
+		content, _ := yaml.Marshal(history)
+		fmt.Print(string(content))
+	default:
+		if len(history) == 0 {
+			fmt.Printf("No snapshots of %s.\n", config.DatabaseFile)
+		}
+		for _, entry := range history {
+			hash := entry.SHA256
+			if len(hash) > 12 {
+				hash = hash[:12]
+			}
+			fmt.Printf("%s  %s  %7d entries  %-12s  %s\n", entry.ID, entry.Timestamp.Local().Format(time.RFC3339), entry.Entries, hash, strings.Join(entry.Sources, ", "))
+		}
+	}
+
+	devMessage("Leaving historyListMain()")
+}
+
+func historyRollbackMain(args []string) {
+	devMessage("Entering historyRollbackMain()")
+	sanitizeArguments()
+	resolveDatabaseFile(false)
+
+	entry, rollbackErr := rollbackDatabase(config.DatabaseFile, args[0])
+	if rollbackErr != nil {
+		stdErr.Printf("Error rolling back database: %s\n", rollbackErr)
+		os.Exit(errHistory)
+	}
+	fmt.Printf("Restored snapshot %s from %s (%d entries) into %s.\n", entry.ID, entry.Timestamp.Local().Format(time.RFC3339), entry.Entries, config.DatabaseFile)
+
+	devMessage("Leaving historyRollbackMain()")
+}
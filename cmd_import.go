@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
+)
+
+func importMain(args []string) {
+	var input io.Reader
+	var sourceURL string
+
+	devMessage("Entering importMain()")
+	sanitizeArguments()
+	resolveDatabaseFile(true)
+
+	fileName := args[0]
+	if fileName == "-" {
+		input = os.Stdin
+		sourceURL = "stdin"
+	} else {
+		fileHandle, fileErr := os.Open(fileName)
+		if fileErr != nil {
+			stdErr.Printf("Error opening import file: %s\n", fileErr)
+			os.Exit(errInputRead)
+		}
+		defer fileHandle.Close()
+		input = fileHandle
+		sourceURL = "file://" + fileName
+		if absFileName, absErr := filepath.Abs(fileName); absErr == nil {
+			sourceURL = "file://" + filepath.ToSlash(absFileName)
+		}
+	}
+
+	content, contentErr := oui.Decompress(input)
+	if contentErr != nil {
+		stdErr.Printf("Error reading import file: %s\n", contentErr)
+		os.Exit(errInputRead)
+	}
+	defer content.Close()
+
+	var database bytes.Buffer
+	if _, readErr := database.ReadFrom(content); readErr != nil {
+		stdErr.Printf("Error reading import file: %s\n", readErr)
+		os.Exit(errInputRead)
+	}
+
+	storeErr := storeDatabase(config.DatabaseFile, database)
+	if storeErr != nil {
+		stdErr.Printf("Error importing database: %s\n", storeErr)
+		os.Exit(errDatabaseUpdate)
+	}
+
+	metadata := databaseMetadata{Sources: []sourceMetadata{{URL: sourceURL, Downloaded: time.Now()}}}
+	if metadataErr := storeDatabaseMetadata(config.DatabaseFile, metadata); metadataErr != nil {
+		stdErr.Printf("Error storing database metadata: %s\n", metadataErr)
+	}
+	if historyErr := addHistorySnapshot(config.DatabaseFile, metadata); historyErr != nil {
+		stdErr.Printf("Warning: Could not record database history: %s\n", historyErr)
+	}
+	fmt.Printf("Imported %s into %s.\n", fileName, config.DatabaseFile)
+
+	devMessage("Leaving importMain()")
+}
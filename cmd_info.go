@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
+)
+
+// databaseInfo describes the local database as reported by the info command.
+type databaseInfo struct {
+	Path          string         `json:"path" yaml:"path"`
+	Format        string         `json:"format" yaml:"format"`
+	Compression   string         `json:"compression" yaml:"compression"`
+	Size          int64          `json:"size" yaml:"size"`
+	Modified      time.Time      `json:"modified" yaml:"modified"`
+	SHA256        string         `json:"sha256" yaml:"sha256"`
+	Sources       []infoSource   `json:"sources,omitempty" yaml:"sources,omitempty"`
+	Checked       *time.Time     `json:"checked,omitempty" yaml:"checked,omitempty"`
+	OUIs          int            `json:"ouis" yaml:"ouis"`
+	Registries    map[string]int `json:"registries" yaml:"registries"`
+	Vendors       int            `json:"vendors" yaml:"vendors"`
+	Organizations int            `json:"organizations" yaml:"organizations"`
+	AgeDays       int            `json:"ageDays" yaml:"ageDays"`
+}
+
+type infoSource struct {
+	URL        string    `json:"url" yaml:"url"`
+	Downloaded time.Time `json:"downloaded" yaml:"downloaded"`
+}
+
+func infoMain() {
+	devMessage("Entering infoMain()")
+	sanitizeArguments()
+	resolveDatabaseFile(false)
+
+	if config.Output.ReportFormat != "text" && config.Output.ReportFormat != "json" && config.Output.ReportFormat != "yaml" {
+		stdErr.Printf("Error: Unsupported output format %s, use text, json or yaml\n", config.Output.ReportFormat)
+		os.Exit(errOutputFormat)
+	}
+
+	aliases, aliasesErr := loadVendorAliases()
+	if aliasesErr != nil {
+		stdErr.Printf("Error loading aliases: %s\n", aliasesErr)
+		os.Exit(errAliasesFile)
+	}
+
+	info, infoErr := inspectDatabase(config.DatabaseFile, aliases)
+	if infoErr != nil {
+		stdErr.Printf("Error loading database: %s\n", infoErr)
+		os.Exit(errDatabaseLoad)
+	}
+
+	switch config.Output.ReportFormat {
+	case "json":
+		content, _ := json.MarshalIndent(info, "", "    ")
+		fmt.Println(string(content))
+	case "yaml":
+		content, _ := yaml.Marshal(info)
+		fmt.Print(string(content))
+	default:
+		fmt.Print(infoText(info))
+	}
+
+	if config.Info.MaxAgeDays > 0 && info.AgeDays > int(config.Info.MaxAgeDays) {
+		stdErr.Printf("Error: Database is %d days old, the maximum is %d days. Run update to fetch a current database.\n", info.AgeDays, config.Info.MaxAgeDays)
+		os.Exit(errDatabaseAge)
+	}
+
+	devMessage("Leaving infoMain()")
+}
+
+// inspectDatabase collects the properties and statistics of a local database.
+// The age is derived from the most recent download of the database or the
+// last update that found it unchanged, or from the modification time of the
+// file if it was not downloaded. Vendor names
+// are grouped into organizations by normalization and aliases.
+func inspectDatabase(fileName string, aliases oui.Aliases) (info databaseInfo, err error) {
+	devMessage("Entering inspectDatabase()")
+
+	stat, statErr := os.Stat(fileName)
+	if statErr != nil {
+		return info, fmt.Errorf("Error reading local OUI database: %s", statErr)
+	}
+	raw, rawErr := loadData(fileName)
+	if rawErr != nil {
+		return info, fmt.Errorf("Error reading local OUI database: %s", rawErr)
+	}
+	info.Path = fileName
+	info.Size = stat.Size()
+	info.Modified = stat.ModTime()
+	info.SHA256 = fmt.Sprintf("%x", sha256.Sum256(raw.Bytes()))
+	info.Compression = oui.DetectCompression(raw.Bytes())
+
+	content, contentErr := oui.Decompress(bytes.NewReader(raw.Bytes()))
+	if contentErr != nil {
+		return info, fmt.Errorf("Error reading local OUI database: %s", contentErr)
+	}
+	head, _ := io.ReadAll(io.LimitReader(content, 64*1024))
+	content.Close()
+	info.Format = oui.DetectFormat(head)
+
+	db, dbErr := loadDatabase(fileName)
+	if dbErr != nil {
+		return info, dbErr
+	}
+	info.OUIs = db.Len()
+	info.Vendors = len(db.Vendors())
+	info.Organizations = len(db.GroupVendors(aliases))
+	info.Registries = make(map[string]int)
+	for _, entry := range db.Entries() {
+		info.Registries[entry.Registry]++
+	}
+
+	var newest time.Time
+	metadata, metadataErr := loadDatabaseMetadata(fileName)
+	if metadataErr == nil {
+		for _, source := range metadata.Sources {
+			if source.Downloaded.IsZero() {
+				// The first download of this source was interrupted.
+				continue
+			}
+			info.Sources = append(info.Sources, infoSource{URL: source.URL, Downloaded: source.Downloaded})
+			if source.Downloaded.After(newest) {
+				newest = source.Downloaded
+			}
+		}
+		if !metadata.Checked.IsZero() {
+			info.Checked = &metadata.Checked
+			if metadata.Checked.After(newest) {
+				newest = metadata.Checked
+			}
+		}
+	}
+	if newest.IsZero() {
+		newest = info.Modified
+	}
+	info.AgeDays = int(time.Since(newest).Hours() / 24)
+
+	devMessage("Leaving inspectDatabase()")
+	return
+}
+
+func infoText(info databaseInfo) string {
+	var text strings.Builder
+
+	fmt.Fprintf(&text, "Path:        %s\n", info.Path)
+	fmt.Fprintf(&text, "Format:      %s (compression: %s)\n", info.Format, info.Compression)
+	fmt.Fprintf(&text, "Size:        %d bytes\n", info.Size)
+	fmt.Fprintf(&text, "Modified:    %s\n", info.Modified.Format(time.RFC3339))
+	fmt.Fprintf(&text, "SHA-256:     %s\n", info.SHA256)
+	if len(info.Sources) == 0 {
+		fmt.Fprintf(&text, "Source:      unknown\n")
+	}
+	for _, source := range info.Sources {
+		fmt.Fprintf(&text, "Source:      %s (downloaded %s)\n", source.URL, source.Downloaded.Format(time.RFC3339))
+	}
+	if info.Checked != nil {
+		fmt.Fprintf(&text, "Checked:     %s\n", info.Checked.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&text, "OUIs:        %d\n", info.OUIs)
+	registries := make([]string, 0, len(info.Registries))
+	for registry := range info.Registries {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+	for _, registry := range registries {
+		fmt.Fprintf(&text, "  %-10s %d\n", registry+":", info.Registries[registry])
+	}
+	fmt.Fprintf(&text, "Vendors:     %d (%d organizations)\n", info.Vendors, info.Organizations)
+	fmt.Fprintf(&text, "Age:         %d days\n", info.AgeDays)
+
+	return text.String()
+}
@@ -1,44 +1,184 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"regexp"
 	"strings"
+	"time"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
 )
 
-func macMain(args []string) {
-	var vendorName string
+var (
+	// MACs in free text, e.g. 00:00:0c:12:34:56 or 00-00-0C-12-34-56
+	reTextMAC = regexp.MustCompile(`^(?:(?:[0-9A-Fa-f]{2}:){5}|(?:[0-9A-Fa-f]{2}-){5})[0-9A-Fa-f]{2}$`)
+)
 
+func macMain(args []string) {
 	devMessage("Entering macMain()")
 	sanitizeArguments()
+	resolveDatabaseFile(false)
 
-	db, dbErr := loadDatabase(config.DatabaseFile)
+	rw, rwErr := newRecordWriter(config.Output.Format, bufio.NewWriter(os.Stdout), macRecordText)
+	if rwErr != nil {
+		stdErr.Printf("Error: %s\n", rwErr)
+		os.Exit(errOutputFormat)
+	}
+
+	databaseFile := config.DatabaseFile
+	if config.MAC.AsOf != "" {
+		asOf, asOfErr := parseAsOf(config.MAC.AsOf)
+		if asOfErr != nil {
+			stdErr.Printf("Error: %s\n", asOfErr)
+			os.Exit(errMissingArgs)
+		}
+		entry, entryErr := historySnapshotAsOf(config.DatabaseFile, asOf)
+		if entryErr != nil {
+			stdErr.Printf("Error finding snapshot: %s\n", entryErr)
+			os.Exit(errHistory)
+		}
+		databaseFile = historySnapshotFileName(config.DatabaseFile, entry)
+		stdErr.Printf("Using snapshot %s taken %s.\n", entry.ID, entry.Timestamp.Local().Format(time.RFC3339))
+	}
+
+	db, release, dbErr := loadResolver(databaseFile)
 	if dbErr != nil {
 		stdErr.Printf("Error loading database: %s\n", dbErr)
 		os.Exit(errDatabaseLoad)
 	}
+	defer release()
 
+	if len(args) == 0 && config.MAC.InputFile == "" {
+		args = []string{"-"}
+	}
 	for _, mac := range args {
-		if !isValidMAC(mac) {
-			stdErr.Printf("Warning: MAC %s is invalid.\n", mac)
+		if mac == "-" {
+			if readErr := macFromReader(db, rw, os.Stdin); readErr != nil {
+				rw.Close()
+				stdErr.Printf("Error reading stdin: %s\n", readErr)
+				os.Exit(errInputRead)
+			}
 			continue
 		}
-		mac = strings.ToLower(mac)
-		oui, ouiErr := extractOUI(mac)
-		if ouiErr != nil {
-			stdErr.Printf("Warning: Unable to map OUI for MAC %s: %s\n", mac, ouiErr)
-			continue
+		rw.Write(macRecord(db, mac))
+	}
+
+	if config.MAC.InputFile != "" {
+		readErr := macFromFile(db, rw, config.MAC.InputFile)
+		if readErr != nil {
+			rw.Close()
+			stdErr.Printf("Error reading input file: %s\n", readErr)
+			os.Exit(errInputRead)
+		}
+	}
+
+	if closeErr := rw.Close(); closeErr != nil {
+		stdErr.Printf("Error writing output: %s\n", closeErr)
+	}
+
+	devMessage("Leaving macMain()")
+}
+
+func macFromFile(db oui.Resolver, rw recordWriter, fileName string) error {
+	if fileName == "-" {
+		return macFromReader(db, rw, os.Stdin)
+	}
+
+	fileHandle, fileErr := os.Open(fileName)
+	if fileErr != nil {
+		return fileErr
+	}
+	defer fileHandle.Close()
+
+	return macFromReader(db, rw, fileHandle)
+}
+
+// macFromReader looks up every MAC found in the lines read from r. Output is
+// flushed whenever reading would block, so results of piped input appear as
+// soon as they are available.
+func macFromReader(db oui.Resolver, rw recordWriter, r io.Reader) error {
+	devMessage("Entering macFromReader()")
+
+	reader := bufio.NewReader(r)
+	for {
+		if reader.Buffered() == 0 {
+			rw.Flush()
 		}
-		if vendorName = db.OUIDatabase[oui].VendorName; vendorName == "" {
-			vendorName = "(unregistered)"
+		line, lineErr := reader.ReadString('\n')
+		macs, hasContent := macsInLine(line)
+		if hasContent && len(macs) == 0 {
+			trimmed := strings.TrimSpace(line)
+			rw.Write(outputRecord{Input: trimmed, Error: fmt.Sprintf("No MAC found in line: %s", trimmed)})
 		}
-		normalized, normalizedErr := normalizeMAC(mac)
-		if normalizedErr != nil {
-			stdErr.Printf("Warning: MAC could not be normalized: %s\n", normalizedErr)
-			continue
+		for _, mac := range macs {
+			rw.Write(macRecord(db, mac))
+		}
+		if lineErr == io.EOF {
+			break
+		}
+		if lineErr != nil {
+			return lineErr
 		}
-		fmt.Printf("%s = %s\n", normalized, vendorName)
 	}
 
-	devMessage("Leaving macMain()")
+	devMessage("Leaving macFromReader()")
+	return nil
+}
+
+// macsInLine returns the MACs to look up for a single line of input. A line
+// is either a MAC on its own or arbitrary text like the output of `ip neigh`,
+// in which case all fields that are complete MACs with separators are
+// returned. Empty lines and comments have no content.
+func macsInLine(line string) (macs []string, hasContent bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	hasContent = true
+
+	fields := strings.Fields(line)
+	if len(fields) == 1 {
+		macs = fields
+		return
+	}
+	for _, field := range fields {
+		field = strings.Trim(field, "()[]{}<>,;\"'")
+		if reTextMAC.MatchString(field) {
+			macs = append(macs, field)
+		}
+	}
+
+	return
+}
+
+func macRecord(db oui.Resolver, mac string) (record outputRecord) {
+	record.Input = mac
+
+	result, resultErr := db.LookupMAC(mac)
+	if resultErr != nil {
+		record.Error = fmt.Sprintf("MAC %s is invalid.", mac)
+		return
+	}
+	record.MAC = result.MAC
+	record.OUI = result.OUI
+	if !result.Found {
+		return
+	}
+	record.OUI = result.Prefix
+	record.PrefixLength = result.PrefixLength
+	record.Registry = result.Registry
+	record.VendorName = result.VendorName
+	record.VendorAddress = result.VendorAddress
+
+	return
+}
+
+func macRecordText(record outputRecord) string {
+	if record.Registry == "" {
+		return fmt.Sprintf("%s = (unregistered)\n", record.MAC)
+	}
+	return fmt.Sprintf("%s = %s (%s, /%d)\n", record.MAC, record.VendorName, record.Registry, record.PrefixLength)
 }
@@ -1,106 +1,800 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	mux "github.com/gorilla/mux"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
+)
+
+type apiError struct {
+	Error    string       `json:"error"`
+	MAC      string       `json:"mac,omitempty"`
+	OUI      string       `json:"oui,omitempty"`
+	Snapshot *apiSnapshot `json:"snapshot,omitempty"`
+}
+
+type apiSnapshot struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type apiMACResult struct {
+	oui.Result
+	Snapshot *apiSnapshot `json:"snapshot,omitempty"`
+}
+
+type apiStatus struct {
+	Tool              string     `json:"tool"`
+	OUIs              int        `json:"ouis"`
+	Vendors           int        `json:"vendors"`
+	LoadedAt          time.Time  `json:"loadedAt"`
+	LastUpdate        *time.Time `json:"lastUpdate,omitempty"`
+	LastUpdateAttempt *time.Time `json:"lastUpdateAttempt,omitempty"`
+	LastUpdateError   string     `json:"lastUpdateError,omitempty"`
+	Endpoints         []string   `json:"endpoints"`
+}
+
+type apiAssignment struct {
+	VendorName    string   `json:"vendorName,omitempty"`
+	Notation      string   `json:"notation"`
+	Prefix        string   `json:"prefix"`
+	PrefixLength  int      `json:"prefixLength"`
+	Registry      string   `json:"registry"`
+	VendorAddress []string `json:"vendorAddress"`
+}
+
+type apiVendor struct {
+	VendorName  string          `json:"vendorName"`
+	Assignments []apiAssignment `json:"assignments"`
+}
+
+type apiVendorMatch struct {
+	VendorName   string   `json:"vendorName"`
+	OUIs         int      `json:"ouis"`
+	Distance     int      `json:"distance,omitempty"`
+	Organization string   `json:"organization,omitempty"`
+	VendorNames  []string `json:"vendorNames,omitempty"`
+}
+
+type apiBulkMAC struct {
+	Input  string      `json:"input"`
+	Result *oui.Result `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type apiBulkVendor struct {
+	Input  string     `json:"input"`
+	Result *apiVendor `json:"result,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// serverDatabase is an immutable snapshot of the database served. Snapshots
+// are swapped atomically on reload.
+type serverDatabase struct {
+	db       *oui.Database
+	aliases  oui.Aliases
+	groups   map[string][]string
+	loadedAt time.Time
+	modTime  time.Time
+	size     int64
+}
+
+// updateStatus records the outcome of the periodic database updates.
+type updateStatus struct {
+	sync.Mutex
+	lastUpdate        time.Time
+	lastUpdateAttempt time.Time
+	lastUpdateError   string
+}
+
+// bulkWriter streams the items of a bulk response in the negotiated format.
+type bulkWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	format  string
+	encoder *json.Encoder
+	count   int
+}
+
+const (
+	// Upper limit for the request body of bulk lookups
+	bulkMaxBodySize int64 = 64 * 1024 * 1024
+	// Number of bulk items after which the response is flushed
+	bulkFlushInterval int = 1000
+	// Number of database snapshots kept in memory for lookups as of a date
+	snapshotCacheSize int = 4
 )
 
 var (
-	persistentOUIDatabase    ouiDatabase
-	persistentVendorDatabase map[string][]string
+	persistentOUIDatabase atomic.Value
+	reloadMutex           sync.Mutex
+	periodicUpdateStatus  updateStatus
+	snapshotDatabases     = make(map[string]*oui.Database)
+	snapshotOrder         []string
+	snapshotMutex         sync.Mutex
+
+	serverEndpoints = []string{"GET /mac/{id}", "GET /mac/{id}?asof={date}", "GET /vendor/{id}", "GET /vendor/{id}?group=true", "GET /vendor/search?q={query}&match={mode}&limit={n}&group={bool}", "POST /mac", "POST /vendor", "POST /admin/reload"}
 )
 
 func serverMain() {
 	devMessage("Entering serverMain()")
 	sanitizeArguments()
+	resolveDatabaseFile(config.Server.UpdateIntervalMinutes > 0)
 
-	db, dbErr := loadDatabase(config.DatabaseFile)
-	if dbErr != nil {
-		stdErr.Printf("Error loading database: %s\n", dbErr)
+	if reloadErr := reloadServerDatabase(); reloadErr != nil {
+		stdErr.Printf("Error loading database: %s\n", reloadErr)
 		os.Exit(errDatabaseLoad)
 	}
-	vendorDB, vendorDBErr := ouiToVendorDatabase(db)
-	if vendorDBErr != nil {
-		stdErr.Printf("Error converting database: %s\n", vendorDBErr)
-		os.Exit(errDatabaseConvert)
+	go reloadOnSignal()
+	if config.Server.WatchIntervalSeconds > 0 {
+		go watchDatabaseFile(time.Duration(config.Server.WatchIntervalSeconds) * time.Second)
+	}
+	if config.Server.UpdateIntervalMinutes > 0 {
+		go updateDatabasePeriodically(time.Duration(config.Server.UpdateIntervalMinutes) * time.Minute)
 	}
-	persistentOUIDatabase = db
-	persistentVendorDatabase = vendorDB
 
 	router := mux.NewRouter().StrictSlash(true)
-	router.HandleFunc("/", handlerRoot)
-	router.HandleFunc("/mac/{id}", handlerMAC)
-	router.HandleFunc("/vendor/{id}", handlerVendor)
-	stdErr.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Server.HTTPPort), router))
+	router.HandleFunc("/", handlerRoot).Methods(http.MethodGet)
+	router.HandleFunc("/mac/{id}", handlerMAC).Methods(http.MethodGet)
+	router.HandleFunc("/vendor/search", handlerVendorSearch).Methods(http.MethodGet)
+	router.HandleFunc("/vendor/{id}", handlerVendor).Methods(http.MethodGet)
+	router.HandleFunc("/mac", handlerBulkMAC).Methods(http.MethodPost)
+	router.HandleFunc("/vendor", handlerBulkVendor).Methods(http.MethodPost)
+	router.HandleFunc("/admin/reload", handlerReload).Methods(http.MethodPost)
+	stdErr.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", config.Server.ListenAddress, config.Server.HTTPPort), router))
 
 	devMessage("Leaving serverMain()")
 }
 
+func currentDatabase() *serverDatabase {
+	return persistentOUIDatabase.Load().(*serverDatabase)
+}
+
+// reloadServerDatabase parses the database file and swaps it in once it has
+// been loaded completely. Requests in flight keep using the previous snapshot.
+func reloadServerDatabase() error {
+	devMessage("Entering reloadServerDatabase()")
+
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	// A missing database file is left to loadDatabase, which falls back to
+	// the embedded database if there is one.
+	var modTime time.Time
+	var size int64
+	info, infoErr := os.Stat(config.DatabaseFile)
+	if infoErr == nil {
+		modTime, size = info.ModTime(), info.Size()
+	} else if !os.IsNotExist(infoErr) {
+		return fmt.Errorf("Could not stat database: %s", infoErr)
+	}
+	db, dbErr := loadDatabase(config.DatabaseFile)
+	if dbErr != nil {
+		return dbErr
+	}
+	aliases, aliasesErr := loadVendorAliases()
+	if aliasesErr != nil {
+		return aliasesErr
+	}
+	persistentOUIDatabase.Store(&serverDatabase{
+		db:       db,
+		aliases:  aliases,
+		groups:   db.GroupVendors(aliases),
+		loadedAt: time.Now(),
+		modTime:  modTime,
+		size:     size,
+	})
+
+	devMessage("Leaving reloadServerDatabase()")
+	return nil
+}
+
+func reloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	for range signals {
+		stdErr.Printf("Received SIGHUP, reloading database\n")
+		if reloadErr := reloadServerDatabase(); reloadErr != nil {
+			stdErr.Printf("Error reloading database: %s\n", reloadErr)
+		}
+	}
+}
+
+// watchDatabaseFile reloads the database whenever modification time or size
+// of the database file change. A file that failed to load is only retried
+// after it has changed again.
+func watchDatabaseFile(interval time.Duration) {
+	current := currentDatabase()
+	seenModTime, seenSize := current.modTime, current.size
+
+	for range time.Tick(interval) {
+		info, infoErr := os.Stat(config.DatabaseFile)
+		if infoErr != nil {
+			devMessage(fmt.Sprintf("Could not stat database: %s", infoErr))
+			continue
+		}
+		current = currentDatabase()
+		if (info.ModTime().Equal(current.modTime) && info.Size() == current.size) ||
+			(info.ModTime().Equal(seenModTime) && info.Size() == seenSize) {
+			continue
+		}
+		seenModTime, seenSize = info.ModTime(), info.Size()
+		stdErr.Printf("Database file changed, reloading database\n")
+		if reloadErr := reloadServerDatabase(); reloadErr != nil {
+			stdErr.Printf("Error reloading database: %s\n", reloadErr)
+		}
+	}
+}
+
+// updateDatabasePeriodically fetches the online database, replaces the local
+// database file and swaps in the new database without interrupting requests.
+func updateDatabasePeriodically(interval time.Duration) {
+	for range time.Tick(interval) {
+		devMessage("Starting periodic database update")
+		updated, updateErr := storeOnlineDatabase(config.Update.DatabaseURLs, config.DatabaseFile)
+		if updateErr == nil && updated {
+			updateErr = reloadServerDatabase()
+		}
+
+		periodicUpdateStatus.Lock()
+		periodicUpdateStatus.lastUpdateAttempt = time.Now()
+		if updateErr != nil {
+			stdErr.Printf("Error updating database: %s\n", updateErr)
+			periodicUpdateStatus.lastUpdateError = updateErr.Error()
+		} else {
+			periodicUpdateStatus.lastUpdate = periodicUpdateStatus.lastUpdateAttempt
+			periodicUpdateStatus.lastUpdateError = ""
+		}
+		periodicUpdateStatus.Unlock()
+	}
+}
+
+// negotiateFormat returns "json", "ndjson" or "text" depending on the Accept
+// header of the request. JSON is preferred if several formats are acceptable.
+func negotiateFormat(r *http.Request) string {
+	qualities := make(map[string]float64)
+
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, mediaTypeErr := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if mediaTypeErr != nil {
+			continue
+		}
+		quality := 1.0
+		if q, qExists := params["q"]; qExists {
+			if parsed, parsedErr := strconv.ParseFloat(q, 64); parsedErr == nil {
+				quality = parsed
+			}
+		}
+		var format string
+		switch mediaType {
+		case "text/plain", "text/*":
+			format = "text"
+		case "application/x-ndjson", "application/ndjson":
+			format = "ndjson"
+		case "application/json", "application/*", "*/*":
+			format = "json"
+		default:
+			continue
+		}
+		if quality > qualities[format] {
+			qualities[format] = quality
+		}
+	}
+
+	best := "json"
+	for _, format := range []string{"ndjson", "text"} {
+		if qualities[format] > qualities[best] {
+			best = format
+		}
+	}
+	return best
+}
+
+// acceptsText reports whether the client prefers text/plain over JSON.
+func acceptsText(r *http.Request) bool {
+	return negotiateFormat(r) == "text"
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	if encodeErr := encoder.Encode(v); encodeErr != nil {
+		devMessage(fmt.Sprintf("Could not encode response: %s", encodeErr))
+	}
+}
+
+func writeText(w http.ResponseWriter, status int, format string, a ...interface{}) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, format, a...)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if acceptsText(r) {
+		writeText(w, status, "Warning: %s\n", message)
+		return
+	}
+	writeJSON(w, status, apiError{Error: message})
+}
+
 func handlerRoot(w http.ResponseWriter, r *http.Request) {
 	devMessage("Entering handlerRoot()")
 
-	fmt.Fprintf(w, "%s\n", toolID)
-	fmt.Fprintf(w, "%d unique OUIs and %d unique vendors in database\n", len(persistentOUIDatabase.OUIDatabase), len(persistentVendorDatabase))
-	fmt.Fprintf(w, "\n")
-	fmt.Fprintf(w, "Usable endpoints:\n")
-	fmt.Fprintf(w, "  /mac/{id}\n")
-	fmt.Fprintf(w, "  /vendor/{id}\n")
+	current := currentDatabase()
+	status := apiStatus{
+		Tool:      toolID,
+		OUIs:      current.db.Len(),
+		Vendors:   len(current.db.Vendors()),
+		LoadedAt:  current.loadedAt,
+		Endpoints: serverEndpoints,
+	}
+	periodicUpdateStatus.Lock()
+	if !periodicUpdateStatus.lastUpdate.IsZero() {
+		lastUpdate := periodicUpdateStatus.lastUpdate
+		status.LastUpdate = &lastUpdate
+	}
+	if !periodicUpdateStatus.lastUpdateAttempt.IsZero() {
+		lastUpdateAttempt := periodicUpdateStatus.lastUpdateAttempt
+		status.LastUpdateAttempt = &lastUpdateAttempt
+	}
+	status.LastUpdateError = periodicUpdateStatus.lastUpdateError
+	periodicUpdateStatus.Unlock()
+
+	if acceptsText(r) {
+		var text strings.Builder
+		fmt.Fprintf(&text, "%s\n", status.Tool)
+		fmt.Fprintf(&text, "%d unique OUIs and %d unique vendors in database\n", status.OUIs, status.Vendors)
+		fmt.Fprintf(&text, "Database loaded at %s\n", status.LoadedAt.Format(time.RFC3339))
+		if status.LastUpdate != nil {
+			fmt.Fprintf(&text, "Last successful update at %s\n", status.LastUpdate.Format(time.RFC3339))
+		}
+		if status.LastUpdateError != "" {
+			fmt.Fprintf(&text, "Last update at %s failed: %s\n", status.LastUpdateAttempt.Format(time.RFC3339), status.LastUpdateError)
+		}
+		fmt.Fprintf(&text, "\n")
+		fmt.Fprintf(&text, "Usable endpoints:\n")
+		for _, endpoint := range status.Endpoints {
+			fmt.Fprintf(&text, "  %s\n", endpoint)
+		}
+		writeText(w, http.StatusOK, "%s", text.String())
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
 
 	devMessage("Leaving handlerRoot()")
 }
 
 func handlerMAC(w http.ResponseWriter, r *http.Request) {
-	devMessage("Entering handlerMAC()")
+	var snapshot *apiSnapshot
 
-	var vendorName string
+	devMessage("Entering handlerMAC()")
 
 	vars := mux.Vars(r)
 	mac := vars["id"]
 
-	if !isValidMAC(mac) {
-		fmt.Fprintf(w, "Warning: MAC %s is invalid.\n", mac)
-		return
+	var db oui.Resolver = currentDatabase().db
+	asOfValue := r.URL.Query().Get("asof")
+	if asOfValue == "" {
+		asOfValue = r.URL.Query().Get("as-of")
+	}
+	if asOfValue != "" {
+		asOf, asOfErr := parseAsOf(asOfValue)
+		if asOfErr != nil {
+			writeError(w, r, http.StatusBadRequest, asOfErr.Error())
+			return
+		}
+		entry, snapshotDB, snapshotErr := snapshotDatabase(asOf)
+		if snapshotErr != nil {
+			writeError(w, r, http.StatusNotFound, snapshotErr.Error())
+			return
+		}
+		db = snapshotDB
+		snapshot = &apiSnapshot{ID: entry.ID, Timestamp: entry.Timestamp}
+		w.Header().Set("X-Snapshot", entry.ID)
 	}
-	mac = strings.ToLower(mac)
-	oui, ouiErr := extractOUI(mac)
-	if ouiErr != nil {
-		fmt.Fprintf(w, "Warning: Unable to map OUI for MAC %s: %s\n", mac, ouiErr)
+
+	result, resultErr := db.LookupMAC(mac)
+	if resultErr != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("MAC %s is invalid.", mac))
 		return
 	}
-	if vendorName = persistentOUIDatabase.OUIDatabase[oui].VendorName; vendorName == "" {
-		vendorName = "(unregistered)"
+	if !result.Found {
+		if acceptsText(r) {
+			writeText(w, http.StatusNotFound, "%s = (unregistered)\n", result.MAC)
+			return
+		}
+		writeJSON(w, http.StatusNotFound, apiError{Error: fmt.Sprintf("MAC %s is not registered.", result.MAC), MAC: result.MAC, OUI: result.OUI, Snapshot: snapshot})
+		return
 	}
-	normalized, normalizedErr := normalizeMAC(mac)
-	if normalizedErr != nil {
-		fmt.Fprintf(w, "Warning: MAC could not be normalized: %s\n", normalizedErr)
+	if acceptsText(r) {
+		writeText(w, http.StatusOK, "%s", macText(result))
 		return
 	}
-	fmt.Fprintf(w, "%s = %s\n", normalized, vendorName)
+	writeJSON(w, http.StatusOK, apiMACResult{Result: result, Snapshot: snapshot})
 
 	devMessage("Leaving handlerMAC()")
 }
 
+// snapshotDatabase returns the database snapshot that was current at the
+// given point in time. Snapshots never change, so they are kept in memory
+// once loaded.
+func snapshotDatabase(asOf time.Time) (entry historyEntry, db *oui.Database, err error) {
+	entry, err = historySnapshotAsOf(config.DatabaseFile, asOf)
+	if err != nil {
+		return
+	}
+
+	snapshotMutex.Lock()
+	defer snapshotMutex.Unlock()
+	db, found := snapshotDatabases[entry.ID]
+	if !found {
+		db, err = loadDatabase(historySnapshotFileName(config.DatabaseFile, entry))
+		if err != nil {
+			return
+		}
+		if len(snapshotOrder) >= snapshotCacheSize {
+			delete(snapshotDatabases, snapshotOrder[0])
+			snapshotOrder = snapshotOrder[1:]
+		}
+		snapshotDatabases[entry.ID] = db
+		snapshotOrder = append(snapshotOrder, entry.ID)
+	}
+	return
+}
+
 func handlerVendor(w http.ResponseWriter, r *http.Request) {
 	devMessage("Entering handlerVendor()")
 
 	vars := mux.Vars(r)
-	vendor := vars["id"]
-	fmt.Fprintf(w, "Would look up vendor %s now.\n", vars["id"])
-
-	if ouis, vendorExists := persistentVendorDatabase[vendor]; vendorExists {
-		for _, oui := range ouis {
-			mac, macErr := normalizeMAC(oui)
-			if macErr != nil {
-				fmt.Fprintf(w, "Warning: MAC could not be normalized: %s\n", macErr)
+
+	current := currentDatabase()
+	var vendorNames []string
+	if group, _ := strconv.ParseBool(r.URL.Query().Get("group")); group {
+		vendorNames = current.groups[current.aliases.Organization(vars["id"])]
+		if vendorNames == nil {
+			vendorNames = []string{}
+		}
+	}
+	vendor, vendorExists := vendorDocument(current.db, vars["id"], vendorNames)
+	if !vendorExists {
+		writeError(w, r, http.StatusNotFound, fmt.Sprintf("Vendor %s is unknown.", vendor.VendorName))
+		return
+	}
+
+	if acceptsText(r) {
+		writeText(w, http.StatusOK, "%s", vendorText(vendor))
+		return
+	}
+	writeJSON(w, http.StatusOK, vendor)
+
+	devMessage("Leaving handlerVendor()")
+}
+
+// handlerVendorSearch returns the vendors matching a query, best matches
+// first. The match mode defaults to substring. With group the results are
+// the organizations of the matching vendors.
+func handlerVendorSearch(w http.ResponseWriter, r *http.Request) {
+	devMessage("Entering handlerVendorSearch()")
+
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		writeError(w, r, http.StatusBadRequest, "Query parameter q is missing.")
+		return
+	}
+	mode := r.URL.Query().Get("match")
+	if mode == "" {
+		mode = oui.MatchSubstring
+	}
+	limit := 0
+	if limitValue := r.URL.Query().Get("limit"); limitValue != "" {
+		var limitErr error
+		limit, limitErr = strconv.Atoi(limitValue)
+		if limitErr != nil || limit < 0 {
+			writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Limit %s is invalid.", limitValue))
+			return
+		}
+	}
+
+	group, _ := strconv.ParseBool(r.URL.Query().Get("group"))
+
+	current := currentDatabase()
+	matches, matchesErr := current.db.SearchVendors(query, mode)
+	if matchesErr != nil {
+		writeError(w, r, http.StatusBadRequest, matchesErr.Error())
+		return
+	}
+	results := make([]apiVendorMatch, 0, len(matches))
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		result := apiVendorMatch{VendorName: match.VendorName, OUIs: len(match.Prefixes), Distance: match.Distance}
+		if group {
+			// Organizations are ranked by their best matching vendor name.
+			result.Organization = current.aliases.Organization(match.VendorName)
+			if seen[result.Organization] {
 				continue
 			}
-			fmt.Fprintf(w, "%s = %s\n", vendor, mac[:8])
+			seen[result.Organization] = true
+			result.VendorNames = current.groups[result.Organization]
+			result.OUIs = 0
+			for _, vendorName := range result.VendorNames {
+				result.OUIs += len(current.db.LookupVendor(vendorName))
+			}
 		}
+		results = append(results, result)
+	}
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
 	}
 
-	devMessage("Leaving handlerVendor()")
+	if acceptsText(r) {
+		var text strings.Builder
+		for _, result := range results {
+			if group {
+				fmt.Fprintf(&text, "%s (%d OUIs): %s\n", result.Organization, result.OUIs, strings.Join(result.VendorNames, "; "))
+				continue
+			}
+			fmt.Fprintf(&text, "%s (%d OUIs)\n", result.VendorName, result.OUIs)
+		}
+		writeText(w, http.StatusOK, "%s", text.String())
+		return
+	}
+	writeJSON(w, http.StatusOK, results)
+
+	devMessage("Leaving handlerVendorSearch()")
+}
+
+func handlerBulkMAC(w http.ResponseWriter, r *http.Request) {
+	devMessage("Entering handlerBulkMAC()")
+
+	macs, inputErr := readBulkInput(w, r)
+	if inputErr != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Could not read request: %s", inputErr))
+		return
+	}
+
+	db := currentDatabase().db
+	bw := newBulkWriter(w, r)
+	for _, mac := range macs {
+		item := apiBulkMAC{Input: mac}
+		result, resultErr := db.LookupMAC(mac)
+		switch {
+		case resultErr != nil:
+			item.Error = fmt.Sprintf("MAC %s is invalid.", mac)
+			bw.Write(item, fmt.Sprintf("Warning: %s\n", item.Error))
+		case !result.Found:
+			item.Error = fmt.Sprintf("MAC %s is not registered.", result.MAC)
+			bw.Write(item, fmt.Sprintf("%s = (unregistered)\n", result.MAC))
+		default:
+			item.Result = &result
+			bw.Write(item, macText(result))
+		}
+	}
+	bw.Close()
+
+	devMessage("Leaving handlerBulkMAC()")
+}
+
+func handlerBulkVendor(w http.ResponseWriter, r *http.Request) {
+	devMessage("Entering handlerBulkVendor()")
+
+	names, inputErr := readBulkInput(w, r)
+	if inputErr != nil {
+		writeError(w, r, http.StatusBadRequest, fmt.Sprintf("Could not read request: %s", inputErr))
+		return
+	}
+
+	db := currentDatabase().db
+	bw := newBulkWriter(w, r)
+	for _, name := range names {
+		item := apiBulkVendor{Input: name}
+		vendor, vendorExists := vendorDocument(db, name, nil)
+		if !vendorExists {
+			item.Error = fmt.Sprintf("Vendor %s is unknown.", name)
+			bw.Write(item, fmt.Sprintf("Warning: %s\n", item.Error))
+			continue
+		}
+		item.Result = &vendor
+		bw.Write(item, vendorText(vendor))
+	}
+	bw.Close()
+
+	devMessage("Leaving handlerBulkVendor()")
+}
+
+func handlerReload(w http.ResponseWriter, r *http.Request) {
+	devMessage("Entering handlerReload()")
+
+	if !config.Server.RemoteAdmin && !isLoopbackRequest(r) {
+		writeError(w, r, http.StatusForbidden, "Reloading is only allowed from localhost.")
+		return
+	}
+	if reloadErr := reloadServerDatabase(); reloadErr != nil {
+		writeError(w, r, http.StatusInternalServerError, fmt.Sprintf("Could not reload database: %s", reloadErr))
+		return
+	}
+	handlerRoot(w, r)
+
+	devMessage("Leaving handlerReload()")
+}
+
+// isLoopbackRequest reports whether the request was sent from the local host.
+func isLoopbackRequest(r *http.Request) bool {
+	host, _, splitErr := net.SplitHostPort(r.RemoteAddr)
+	if splitErr != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func macText(result oui.Result) string {
+	return fmt.Sprintf("%s = %s (%s, /%d)\n", result.MAC, result.VendorName, result.Registry, result.PrefixLength)
+}
+
+// vendorDocument describes the assignments of a vendor. If vendorNames is
+// not nil, the assignments of all these vendor names are described instead,
+// each along with its vendor name.
+func vendorDocument(db *oui.Database, name string, vendorNames []string) (vendor apiVendor, vendorExists bool) {
+	vendor = apiVendor{VendorName: name, Assignments: []apiAssignment{}}
+
+	grouped := vendorNames != nil
+	if !grouped {
+		vendorNames = []string{name}
+	}
+
+	for _, vendorName := range vendorNames {
+		for _, prefix := range db.LookupVendor(vendorName) {
+			formatted, formattedErr := oui.FormatPrefix(prefix)
+			if formattedErr != nil {
+				devMessage(fmt.Sprintf("MAC could not be normalized: %s", formattedErr))
+				continue
+			}
+			entry, _ := db.Entry(prefix)
+			assignment := apiAssignment{
+				Notation:      formatted,
+				Prefix:        prefix,
+				PrefixLength:  len(prefix) * 4,
+				Registry:      entry.Registry,
+				VendorAddress: entry.VendorAddress,
+			}
+			if grouped {
+				assignment.VendorName = vendorName
+			}
+			vendor.Assignments = append(vendor.Assignments, assignment)
+			vendorExists = true
+		}
+	}
+
+	return
+}
+
+func vendorText(vendor apiVendor) string {
+	var text strings.Builder
+
+	for _, assignment := range vendor.Assignments {
+		vendorName := vendor.VendorName
+		if assignment.VendorName != "" {
+			vendorName = assignment.VendorName
+		}
+		fmt.Fprintf(&text, "%s = %s\n", vendorName, assignment.Notation)
+	}
+
+	return text.String()
+}
+
+// readBulkInput reads the complete body of a bulk request and returns its
+// items. The body is either a JSON array of strings or a newline separated
+// list. The body has to be read completely before the response is started,
+// as the server closes the request body once the first response bytes are
+// written.
+func readBulkInput(w http.ResponseWriter, r *http.Request) (items []string, err error) {
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, bulkMaxBodySize))
+
+	for {
+		peeked, peekErr := body.Peek(1)
+		if peekErr == io.EOF {
+			return
+		}
+		if peekErr != nil {
+			err = peekErr
+			return
+		}
+		if !bytes.ContainsAny(peeked, " \t\r\n") {
+			break
+		}
+		body.ReadByte()
+	}
+
+	peeked, _ := body.Peek(1)
+	if peeked[0] == '[' {
+		var list []string
+		if decodeErr := json.NewDecoder(body).Decode(&list); decodeErr != nil {
+			err = decodeErr
+			return
+		}
+		for _, item := range list {
+			items = append(items, strings.TrimSpace(item))
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		if item := strings.TrimSpace(scanner.Text()); item != "" {
+			items = append(items, item)
+		}
+	}
+	err = scanner.Err()
+
+	return
+}
+
+func newBulkWriter(w http.ResponseWriter, r *http.Request) *bulkWriter {
+	bw := &bulkWriter{w: w, format: negotiateFormat(r), encoder: json.NewEncoder(w)}
+	bw.flusher, _ = w.(http.Flusher)
+
+	switch bw.format {
+	case "text":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	default:
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	w.WriteHeader(http.StatusOK)
+	if bw.format == "json" {
+		io.WriteString(w, "[\n")
+	}
+
+	return bw
+}
+
+// Write sends a single item, either encoded as JSON or as the given text.
+func (bw *bulkWriter) Write(item interface{}, text string) {
+	switch bw.format {
+	case "text":
+		io.WriteString(bw.w, text)
+	case "ndjson":
+		bw.encoder.Encode(item)
+	default:
+		if bw.count > 0 {
+			io.WriteString(bw.w, ",\n")
+		}
+		encoded, _ := json.Marshal(item)
+		bw.w.Write(encoded)
+	}
+
+	bw.count++
+	if bw.flusher != nil && bw.count%bulkFlushInterval == 0 {
+		bw.flusher.Flush()
+	}
+}
+
+// Close terminates the response.
+func (bw *bulkWriter) Close() {
+	if bw.format == "json" {
+		if bw.count > 0 {
+			io.WriteString(bw.w, "\n")
+		}
+		io.WriteString(bw.w, "]\n")
+	}
 }
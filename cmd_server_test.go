@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
+)
+
+const bulkTestItems = 5000
+
+func startBulkTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	db := oui.NewDatabase(map[string]oui.Entry{
+		"00000c": {VendorName: "Cisco Systems, Inc", Registry: "MA-L"},
+	})
+	persistentOUIDatabase.Store(&serverDatabase{db: db, loadedAt: time.Now()})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mac", handlerBulkMAC)
+	mux.HandleFunc("/vendor", handlerBulkVendor)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func bulkTestMACs() []string {
+	macs := make([]string, bulkTestItems)
+	for i := range macs {
+		macs[i] = fmt.Sprintf("00:00:0c:%02x:%02x:%02x", (i>>16)&0xff, (i>>8)&0xff, i&0xff)
+	}
+	return macs
+}
+
+func postBulk(t *testing.T, url string, accept string, body []byte) []byte {
+	t.Helper()
+
+	request, requestErr := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if requestErr != nil {
+		t.Fatal(requestErr)
+	}
+	request.Header.Set("Accept", accept)
+	response, responseErr := http.DefaultClient.Do(request)
+	if responseErr != nil {
+		t.Fatal(responseErr)
+	}
+	defer response.Body.Close()
+
+	var buffer bytes.Buffer
+	if _, readErr := buffer.ReadFrom(response.Body); readErr != nil {
+		t.Fatal(readErr)
+	}
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", response.StatusCode, buffer.String())
+	}
+	return buffer.Bytes()
+}
+
+func TestBulkMACLargeRequest(t *testing.T) {
+	server := startBulkTestServer(t)
+	macs := bulkTestMACs()
+	jsonBody, _ := json.Marshal(macs)
+	textBody := []byte(strings.Join(macs, "\n") + "\n")
+
+	tests := []struct {
+		name   string
+		accept string
+		body   []byte
+	}{
+		{"json array to json", "application/json", jsonBody},
+		{"json array to ndjson", "application/x-ndjson", jsonBody},
+		{"text list to json", "application/json", textBody},
+		{"text list to text", "text/plain", textBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := postBulk(t, server.URL+"/mac", tt.accept, tt.body)
+
+			var items []apiBulkMAC
+			switch tt.accept {
+			case "application/json":
+				if decodeErr := json.Unmarshal(response, &items); decodeErr != nil {
+					t.Fatalf("invalid JSON response: %s", decodeErr)
+				}
+			case "application/x-ndjson":
+				scanner := bufio.NewScanner(bytes.NewReader(response))
+				for scanner.Scan() {
+					var item apiBulkMAC
+					if decodeErr := json.Unmarshal(scanner.Bytes(), &item); decodeErr != nil {
+						t.Fatalf("invalid NDJSON line %q: %s", scanner.Text(), decodeErr)
+					}
+					items = append(items, item)
+				}
+			default:
+				lines := strings.Split(strings.TrimSpace(string(response)), "\n")
+				for _, line := range lines {
+					if !strings.HasSuffix(line, "= Cisco Systems, Inc (MA-L, /24)") {
+						t.Fatalf("unexpected line %q", line)
+					}
+				}
+				if len(lines) != bulkTestItems {
+					t.Fatalf("got %d lines, want %d", len(lines), bulkTestItems)
+				}
+				return
+			}
+
+			if len(items) != bulkTestItems {
+				t.Fatalf("got %d items, want %d", len(items), bulkTestItems)
+			}
+			for i, item := range items {
+				if item.Error != "" || item.Result == nil || item.Input != macs[i] {
+					t.Fatalf("item %d = %+v, want result for %s", i, item, macs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBulkVendorLargeRequest(t *testing.T) {
+	server := startBulkTestServer(t)
+	names := make([]string, bulkTestItems)
+	for i := range names {
+		names[i] = "Cisco Systems, Inc"
+	}
+	body, _ := json.Marshal(names)
+
+	var items []apiBulkVendor
+	if decodeErr := json.Unmarshal(postBulk(t, server.URL+"/vendor", "application/json", body), &items); decodeErr != nil {
+		t.Fatalf("invalid JSON response: %s", decodeErr)
+	}
+	if len(items) != bulkTestItems {
+		t.Fatalf("got %d items, want %d", len(items), bulkTestItems)
+	}
+}
+
+func TestBulkInvalidRequest(t *testing.T) {
+	server := startBulkTestServer(t)
+
+	response, responseErr := http.Post(server.URL+"/mac", "application/json", strings.NewReader(`["00:00:0c", 42]`))
+	if responseErr != nil {
+		t.Fatal(responseErr)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", response.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestReloadAccess(t *testing.T) {
+	startBulkTestServer(t)
+	config.DatabaseFile = filepath.Join(t.TempDir(), "oui.txt")
+	config.NoCache = true
+	t.Cleanup(func() { config = appConfig{} })
+	if writeErr := os.WriteFile(config.DatabaseFile, []byte(historyTestGood), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	tests := []struct {
+		remoteAddr  string
+		remoteAdmin bool
+		want        int
+	}{
+		{"127.0.0.1:40000", false, http.StatusOK},
+		{"[::1]:40000", false, http.StatusOK},
+		{"192.0.2.1:40000", false, http.StatusForbidden},
+		{"[2001:db8::1]:40000", false, http.StatusForbidden},
+		{"192.0.2.1:40000", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%t", tt.remoteAddr, tt.remoteAdmin), func(t *testing.T) {
+			config.Server.RemoteAdmin = tt.remoteAdmin
+			request := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+			request.RemoteAddr = tt.remoteAddr
+			recorder := httptest.NewRecorder()
+			handlerReload(recorder, request)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -1,18 +1,44 @@
 package main
 
 import (
+	"fmt"
 	"os"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
 )
 
 func updateMain() {
+	var previousDB *oui.Database
+
 	devMessage("Entering updateMain()")
 	sanitizeArguments()
+	resolveDatabaseFile(true)
 
-	updateErr := storeOnlineDatabase(config.Update.DatabaseURL, config.DatabaseFile)
+	if config.Update.ShowDiff {
+		if _, statErr := os.Stat(config.DatabaseFile); statErr == nil {
+			db, dbErr := loadDatabase(config.DatabaseFile)
+			if dbErr != nil {
+				stdErr.Printf("Warning: Not showing changes, the current database could not be loaded: %s\n", dbErr)
+			}
+			previousDB = db
+		}
+	}
+
+	updated, updateErr := storeOnlineDatabase(config.Update.DatabaseURLs, config.DatabaseFile)
 	if updateErr != nil {
 		stdErr.Printf("Error updating local database: %s\n", updateErr)
 		os.Exit(errDatabaseUpdate)
 	}
+	if !updated {
+		fmt.Printf("Local database %s is already up to date.\n", config.DatabaseFile)
+	} else if previousDB != nil {
+		currentDB, currentDBErr := loadDatabase(config.DatabaseFile)
+		if currentDBErr != nil {
+			stdErr.Printf("Error loading database: %s\n", currentDBErr)
+			os.Exit(errDatabaseLoad)
+		}
+		fmt.Print(diffText(oui.Diff(previousDB, currentDB)))
+	}
 
 	devMessage("Leaving updateMain()")
 }
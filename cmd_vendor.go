@@ -1,37 +1,117 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"os"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
 )
 
 func vendorMain(args []string) {
 	devMessage("Entering vendorMain()")
 	sanitizeArguments()
+	resolveDatabaseFile(false)
+
+	rw, rwErr := newRecordWriter(config.Output.Format, bufio.NewWriter(os.Stdout), vendorRecordText)
+	if rwErr != nil {
+		stdErr.Printf("Error: %s\n", rwErr)
+		os.Exit(errOutputFormat)
+	}
+
+	switch config.Vendor.MatchMode {
+	case oui.MatchExact, oui.MatchSubstring, oui.MatchPrefix, oui.MatchRegex, oui.MatchFuzzy:
+	default:
+		stdErr.Printf("Error: Unknown match mode %s\n", config.Vendor.MatchMode)
+		os.Exit(errMatchMode)
+	}
 
 	db, dbErr := loadDatabase(config.DatabaseFile)
 	if dbErr != nil {
 		stdErr.Printf("Error loading database: %s\n", dbErr)
 		os.Exit(errDatabaseLoad)
 	}
-	vendorDB, vendorDBErr := ouiToVendorDatabase(db)
-	if vendorDBErr != nil {
-		stdErr.Printf("Error converting database: %s\n", vendorDBErr)
-		os.Exit(errDatabaseConvert)
+
+	var aliases oui.Aliases
+	var groups map[string][]string
+	if config.Vendor.Group {
+		var aliasesErr error
+		aliases, aliasesErr = loadVendorAliases()
+		if aliasesErr != nil {
+			stdErr.Printf("Error loading aliases: %s\n", aliasesErr)
+			os.Exit(errAliasesFile)
+		}
+		groups = db.GroupVendors(aliases)
 	}
 
 	for _, vendor := range args {
-		if ouis, vendorExists := vendorDB[vendor]; vendorExists {
-			for _, oui := range ouis {
-				mac, macErr := normalizeMAC(oui)
-				if macErr != nil {
-					stdErr.Printf("Warning: MAC could not be normalized: %s\n", macErr)
-					continue
-				}
-				fmt.Printf("%s = %s\n", vendor, mac[:8])
+		matches, matchesErr := vendorMatches(db, groups, aliases, vendor, config.Vendor.MatchMode)
+		if matchesErr != nil {
+			rw.Write(outputRecord{Input: vendor, Error: matchesErr.Error()})
+			continue
+		}
+		if len(matches) == 0 {
+			rw.Write(outputRecord{Input: vendor, Error: fmt.Sprintf("Vendor %s is unknown.", vendor)})
+			continue
+		}
+		for _, match := range matches {
+			for _, prefix := range match.Prefixes {
+				entry, _ := db.Entry(prefix)
+				rw.Write(outputRecord{
+					Input:         vendor,
+					OUI:           prefix,
+					PrefixLength:  len(prefix) * 4,
+					Registry:      entry.Registry,
+					VendorName:    entry.VendorName,
+					VendorAddress: entry.VendorAddress,
+				})
 			}
 		}
 	}
 
+	if closeErr := rw.Close(); closeErr != nil {
+		stdErr.Printf("Error writing output: %s\n", closeErr)
+	}
+
 	devMessage("Leaving vendorMain()")
 }
+
+// vendorMatches searches the vendors matching the query. If groups is set,
+// the matches are replaced by all vendor names of their organizations; in
+// exact mode the organization of the query itself is used.
+func vendorMatches(db *oui.Database, groups map[string][]string, aliases oui.Aliases, query string, mode string) (matches []oui.VendorMatch, err error) {
+	if groups == nil {
+		return db.SearchVendors(query, mode)
+	}
+
+	organizations := []string{aliases.Organization(query)}
+	if mode != oui.MatchExact {
+		found, foundErr := db.SearchVendors(query, mode)
+		if foundErr != nil {
+			return nil, foundErr
+		}
+		organizations = nil
+		seen := make(map[string]bool)
+		for _, match := range found {
+			organization := aliases.Organization(match.VendorName)
+			if !seen[organization] {
+				seen[organization] = true
+				organizations = append(organizations, organization)
+			}
+		}
+	}
+	for _, organization := range organizations {
+		for _, vendorName := range groups[organization] {
+			matches = append(matches, oui.VendorMatch{VendorName: vendorName, Prefixes: db.LookupVendor(vendorName)})
+		}
+	}
+	return
+}
+
+func vendorRecordText(record outputRecord) string {
+	prefix, prefixErr := oui.FormatPrefix(record.OUI)
+	if prefixErr != nil {
+		prefix = record.OUI
+	}
+	return fmt.Sprintf("%s = %s\n", record.VendorName, prefix)
+}
@@ -0,0 +1,15 @@
+//go:build embeddb
+// +build embeddb
+
+package main
+
+import (
+	_ "embed"
+)
+
+// embeddedDatabase is a snapshot of the database that is used if the local
+// database file does not exist. Run "make embed-db" to fetch it before
+// building with the embeddb tag.
+//
+//go:embed data/oui.txt.gz
+var embeddedDatabase []byte
@@ -0,0 +1,7 @@
+//go:build !embeddb
+// +build !embeddb
+
+package main
+
+// embeddedDatabase is empty unless built with the embeddb tag.
+var embeddedDatabase []byte
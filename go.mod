@@ -5,12 +5,15 @@ go 1.17
 require (
 	github.com/go-resty/resty/v2 v2.7.0
 	github.com/gorilla/mux v1.8.0
+	github.com/klauspost/compress v1.15.15
 	github.com/spf13/cobra v1.3.0
+	github.com/spf13/pflag v1.0.5
+	github.com/ulikunitz/xz v0.5.15
 	gitlab.com/rbrt-weiler/go-module-envordef v0.1.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/net v0.0.0-20211216030914-fe4d6282115f // indirect
 )
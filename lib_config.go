@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
+)
+
+/*
+######## ##     ## ##    ##  ######   ######
+##       ##     ## ###   ## ##    ## ##    ##
+##       ##     ## ####  ## ##       ##
+######   ##     ## ## ## ## ##        ######
+##       ##     ## ##  #### ##             ##
+##       ##     ## ##   ### ##    ## ##    ##
+##        #######  ##    ##  ######   ######
+*/
+
+// defaultConfig returns the hardcoded defaults of all settings that can be
+// given in a config file.
+func defaultConfig() (defaults appConfig) {
+	defaults.Update.DatabaseURLs = strings.Split(ouiDatabaseURLs, ",")
+	defaults.Update.HTTPTimeoutSeconds = 60
+	defaults.Update.MaxShrinkPercent = 25
+	defaults.Update.HistorySize = 5
+	defaults.Update.HistoryDays = 365
+	defaults.Export.OutputFormat = "csv"
+	defaults.Output.Format = "text"
+	defaults.Vendor.MatchMode = oui.MatchExact
+	defaults.Server.HTTPPort = 8000
+	defaults.Server.WatchIntervalSeconds = 5
+	return
+}
+
+// userConfigDir returns the per-user config directory of the tool, following
+// the XDG Base Directory Specification.
+func userConfigDir() string {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if !filepath.IsAbs(configHome) {
+		homeDir, homeDirErr := os.UserHomeDir()
+		if homeDirErr != nil {
+			return ""
+		}
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	return filepath.Join(configHome, dataDirName)
+}
+
+// configSearchPath returns the locations that are searched for a config file,
+// in order of preference.
+func configSearchPath() (path []string) {
+	if configDir := userConfigDir(); configDir != "" {
+		path = append(path, filepath.Join(configDir, configFileName))
+	}
+	path = append(path, filepath.Join(systemConfigDir, configFileName))
+	return
+}
+
+// configFileFromArgs returns the config file given with --config or in
+// OUILOOKUP_CONFIG. The config file provides the defaults of the flags, so it
+// has to be known before the flags are parsed.
+func configFileFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--config" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, "--config=") {
+			return strings.TrimPrefix(arg, "--config=")
+		}
+	}
+	return os.Getenv("OUILOOKUP_CONFIG")
+}
+
+// loadConfigFile returns the hardcoded defaults overridden by the settings of
+// a config file. Without fileName the first existing file of the search path
+// is used; it is no error if none exists.
+func loadConfigFile(fileName string) (defaults appConfig, usedFile string, err error) {
+	devMessage("Entering loadConfigFile()")
+
+	defaults = defaultConfig()
+	if fileName == "" {
+		for _, candidate := range configSearchPath() {
+			if _, statErr := os.Stat(candidate); statErr == nil {
+				fileName = candidate
+				break
+			}
+		}
+		if fileName == "" {
+			return
+		}
+	}
+
+	content, contentErr := os.ReadFile(fileName)
+	if contentErr != nil {
+		return defaults, usedFile, fmt.Errorf("Could not read config file: %s", contentErr)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if decodeErr := decoder.Decode(&defaults); decodeErr != nil && decodeErr != io.EOF {
+		return defaults, usedFile, fmt.Errorf("Could not parse config file %s: %s", fileName, decodeErr)
+	}
+	usedFile = fileName
+
+	devMessage("Leaving loadConfigFile()")
+	return
+}
+
+// loadVendorAliases reads the organizations and the vendor names belonging to
+// them from config.Vendor.AliasesFile or, if that is not set, from the first
+// aliases file found in the config directories. No aliases file is no error.
+func loadVendorAliases() (aliases oui.Aliases, err error) {
+	var organizations map[string][]string
+
+	devMessage("Entering loadVendorAliases()")
+
+	fileName := config.Vendor.AliasesFile
+	if fileName == "" {
+		for _, candidate := range configSearchPath() {
+			candidate = filepath.Join(filepath.Dir(candidate), aliasesFileName)
+			if _, statErr := os.Stat(candidate); statErr == nil {
+				fileName = candidate
+				break
+			}
+		}
+		if fileName == "" {
+			return oui.NewAliases(nil), nil
+		}
+	}
+
+	content, contentErr := os.ReadFile(fileName)
+	if contentErr != nil {
+		return aliases, fmt.Errorf("Could not read aliases file: %s", contentErr)
+	}
+	if yamlErr := yaml.Unmarshal(content, &organizations); yamlErr != nil {
+		return aliases, fmt.Errorf("Could not parse aliases file %s: %s", fileName, yamlErr)
+	}
+	aliases = oui.NewAliases(organizations)
+
+	devMessage("Leaving loadVendorAliases()")
+	return
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+######## ##    ## ########  ########  ######
+   ##     ##  ##  ##     ## ##       ##    ##
+   ##      ####   ##     ## ##       ##
+   ##       ##    ########  ######    ######
+   ##       ##    ##        ##             ##
+   ##       ##    ##        ##       ##    ##
+   ##       ##    ##        ########  ######
+*/
+
+// historyEntry describes a snapshot of the local database that was taken
+// after an update or import.
+type historyEntry struct {
+	ID        string    `json:"id" yaml:"id"`
+	Timestamp time.Time `json:"timestamp" yaml:"timestamp"`
+	File      string    `json:"file" yaml:"file"`
+	Sources   []string  `json:"sources" yaml:"sources"`
+	SHA256    string    `json:"sha256" yaml:"sha256"`
+	Entries   int       `json:"entries" yaml:"entries"`
+}
+
+/*
+######## ##     ## ##    ##  ######   ######
+##       ##     ## ###   ## ##    ## ##    ##
+##       ##     ## ####  ## ##       ##
+######   ##     ## ## ## ## ##        ######
+##       ##     ## ##  #### ##             ##
+##       ##     ## ##   ### ##    ## ##    ##
+##        #######  ##    ##  ######   ######
+*/
+
+func historyDirName(fileName string) string {
+	return fileName + ".history"
+}
+
+func historyIndexFileName(fileName string) string {
+	return filepath.Join(historyDirName(fileName), "history.json")
+}
+
+func historySnapshotFileName(fileName string, entry historyEntry) string {
+	return filepath.Join(historyDirName(fileName), entry.File)
+}
+
+// loadHistory returns the snapshots of a database, oldest first. A missing
+// history is no error.
+func loadHistory(fileName string) (history []historyEntry, err error) {
+	devMessage("Entering loadHistory()")
+
+	content, contentErr := os.ReadFile(historyIndexFileName(fileName))
+	if contentErr != nil {
+		if os.IsNotExist(contentErr) {
+			return
+		}
+		return history, fmt.Errorf("Could not read history: %s", contentErr)
+	}
+	if jsonErr := json.Unmarshal(content, &history); jsonErr != nil {
+		return history, fmt.Errorf("Could not decode history: %s", jsonErr)
+	}
+
+	devMessage("Leaving loadHistory()")
+	return
+}
+
+func storeHistory(fileName string, history []historyEntry) error {
+	devMessage("Entering storeHistory()")
+
+	content, contentErr := json.MarshalIndent(history, "", "    ")
+	if contentErr != nil {
+		return fmt.Errorf("Could not encode history: %s", contentErr)
+	}
+
+	devMessage("Leaving storeHistory()")
+	return storeData(historyIndexFileName(fileName), *bytes.NewBuffer(content))
+}
+
+// addHistorySnapshot copies the current local database into the history and
+// removes the oldest snapshots beyond config.Update.HistorySize that are
+// older than config.Update.HistoryDays. Nothing is recorded if the database
+// file is identical to the most recent snapshot.
+func addHistorySnapshot(fileName string, metadata databaseMetadata) error {
+	devMessage("Entering addHistorySnapshot()")
+
+	if config.Update.HistorySize == 0 && config.Update.HistoryDays == 0 {
+		return nil
+	}
+	history, historyErr := loadHistory(fileName)
+	if historyErr != nil {
+		return historyErr
+	}
+	content, contentErr := loadData(fileName)
+	if contentErr != nil {
+		return fmt.Errorf("Could not read database: %s", contentErr)
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256(content.Bytes()))
+	if len(history) > 0 && history[len(history)-1].SHA256 == hash {
+		devMessage("Database equals the most recent snapshot")
+		return nil
+	}
+	db, dbErr := loadDatabase(fileName)
+	if dbErr != nil {
+		return dbErr
+	}
+
+	now := time.Now().UTC()
+	entry := historyEntry{
+		ID:        now.Format("20060102T150405Z"),
+		Timestamp: now,
+		SHA256:    hash,
+		Entries:   db.Len(),
+	}
+	entry.File = entry.ID + "-" + filepath.Base(fileName)
+	for _, source := range metadata.Sources {
+		entry.Sources = append(entry.Sources, source.URL)
+	}
+	if storeErr := storeData(historySnapshotFileName(fileName, entry), content); storeErr != nil {
+		return storeErr
+	}
+	if len(history) > 0 && history[len(history)-1].ID == entry.ID {
+		history = history[:len(history)-1]
+	}
+	history = append(history, entry)
+	keepSince := now.AddDate(0, 0, -int(config.Update.HistoryDays))
+	for len(history) > int(config.Update.HistorySize) && history[0].Timestamp.Before(keepSince) {
+		snapshotFile := historySnapshotFileName(fileName, history[0])
+		if removeErr := os.Remove(snapshotFile); removeErr != nil && !os.IsNotExist(removeErr) {
+			return fmt.Errorf("Could not remove snapshot %s: %s", history[0].ID, removeErr)
+		}
+		os.Remove(cacheFileName(snapshotFile))
+		history = history[1:]
+	}
+
+	devMessage("Leaving addHistorySnapshot()")
+	return storeHistory(fileName, history)
+}
+
+// rollbackDatabase replaces the local database with a snapshot. The metadata
+// is replaced as well, so the next update downloads the databases again. The
+// restored database is recorded as a new snapshot, so lookups as of a later
+// date use it instead of the snapshot that was rolled back.
+func rollbackDatabase(fileName string, id string) (entry historyEntry, err error) {
+	devMessage("Entering rollbackDatabase()")
+
+	history, historyErr := loadHistory(fileName)
+	if historyErr != nil {
+		return entry, historyErr
+	}
+	found := false
+	for _, candidate := range history {
+		if candidate.ID == id {
+			entry = candidate
+			found = true
+		}
+	}
+	if !found {
+		return entry, fmt.Errorf("Snapshot %s does not exist", id)
+	}
+
+	content, contentErr := loadData(historySnapshotFileName(fileName, entry))
+	if contentErr != nil {
+		return entry, fmt.Errorf("Could not read snapshot %s: %s", id, contentErr)
+	}
+	if hash := fmt.Sprintf("%x", sha256.Sum256(content.Bytes())); hash != entry.SHA256 {
+		return entry, fmt.Errorf("Snapshot %s is corrupted", id)
+	}
+	if storeErr := storeData(fileName, content); storeErr != nil {
+		return entry, storeErr
+	}
+
+	var metadata databaseMetadata
+	for _, url := range entry.Sources {
+		metadata.Sources = append(metadata.Sources, sourceMetadata{URL: url, Downloaded: entry.Timestamp})
+	}
+	if metadataErr := storeDatabaseMetadata(fileName, metadata); metadataErr != nil {
+		return entry, fmt.Errorf("Error storing database metadata: %s", metadataErr)
+	}
+	if historyErr := addHistorySnapshot(fileName, metadata); historyErr != nil {
+		return entry, fmt.Errorf("Error recording rollback in history: %s", historyErr)
+	}
+
+	devMessage("Leaving rollbackDatabase()")
+	return
+}
+
+// parseAsOf parses a point in time given as date, which means the end of the
+// day in UTC, or as RFC 3339 timestamp.
+func parseAsOf(value string) (asOf time.Time, err error) {
+	if asOf, err = time.Parse("2006-01-02", value); err == nil {
+		asOf = asOf.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		return
+	}
+	if asOf, err = time.Parse(time.RFC3339, value); err == nil {
+		return
+	}
+	return asOf, fmt.Errorf("Invalid date %s, use YYYY-MM-DD or RFC 3339", value)
+}
+
+// historySnapshotAsOf returns the snapshot that was the current database at
+// the given point in time, i.e. the most recent one taken until then.
+func historySnapshotAsOf(fileName string, asOf time.Time) (entry historyEntry, err error) {
+	devMessage("Entering historySnapshotAsOf()")
+
+	history, historyErr := loadHistory(fileName)
+	if historyErr != nil {
+		return entry, historyErr
+	}
+	found := false
+	for _, candidate := range history {
+		if !candidate.Timestamp.After(asOf) {
+			entry = candidate
+			found = true
+		}
+	}
+	if !found {
+		if len(history) == 0 {
+			return entry, fmt.Errorf("No snapshots of %s exist", fileName)
+		}
+		return entry, fmt.Errorf("No snapshot was taken until %s, the oldest is from %s (see --historydays)", asOf.Format(time.RFC3339), history[0].Timestamp.Format(time.RFC3339))
+	}
+
+	devMessage("Leaving historySnapshotAsOf()")
+	return
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	historyTestGood = "OUI-28/MA-M\t\tOrganization\n\n" +
+		"00-55-DA   (hex)\t\tShinko Technos co.,ltd.\n" +
+		"000000-0FFFFF     (base 16)\t\tShinko Technos co.,ltd.\n" +
+		"\t\t\t\tJP\n"
+	historyTestBad = "OUI/MA-L\t\t\tOrganization\n\n" +
+		"00-55-DA   (hex)\t\tIEEE Registration Authority\n" +
+		"0055DA     (base 16)\t\tIEEE Registration Authority\n" +
+		"\t\t\t\tUS\n"
+)
+
+// setupHistoryTest creates a database file with the given snapshots, oldest
+// first, and the content of the last one as current database.
+func setupHistoryTest(t *testing.T, timestamps []time.Time, contents []string) string {
+	t.Helper()
+
+	fileName := filepath.Join(t.TempDir(), "oui.txt")
+	config.NoCache = true
+	config.Update.HistorySize = 5
+	config.Update.HistoryDays = 365
+	t.Cleanup(func() { config = appConfig{} })
+
+	var history []historyEntry
+	for i, content := range contents {
+		entry := historyEntry{
+			ID:        timestamps[i].Format("20060102T150405Z"),
+			Timestamp: timestamps[i],
+			SHA256:    fmt.Sprintf("%x", sha256.Sum256([]byte(content))),
+			Entries:   1,
+		}
+		entry.File = entry.ID + "-" + filepath.Base(fileName)
+		if storeErr := storeData(historySnapshotFileName(fileName, entry), *bytes.NewBufferString(content)); storeErr != nil {
+			t.Fatal(storeErr)
+		}
+		history = append(history, entry)
+	}
+	if storeErr := storeHistory(fileName, history); storeErr != nil {
+		t.Fatal(storeErr)
+	}
+	if storeErr := storeData(fileName, *bytes.NewBufferString(contents[len(contents)-1])); storeErr != nil {
+		t.Fatal(storeErr)
+	}
+
+	return fileName
+}
+
+func lookupVendorAsOf(t *testing.T, fileName string, asOf time.Time) string {
+	t.Helper()
+
+	entry, entryErr := historySnapshotAsOf(fileName, asOf)
+	if entryErr != nil {
+		t.Fatalf("historySnapshotAsOf() error = %s", entryErr)
+	}
+	db, dbErr := loadDatabase(historySnapshotFileName(fileName, entry))
+	if dbErr != nil {
+		t.Fatal(dbErr)
+	}
+	result, resultErr := db.LookupMAC("00:55:da:01:02:03")
+	if resultErr != nil {
+		t.Fatal(resultErr)
+	}
+	return result.VendorName
+}
+
+func TestRollbackThenAsOf(t *testing.T) {
+	goodTime := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	badTime := time.Date(2020, 2, 1, 12, 0, 0, 0, time.UTC)
+	fileName := setupHistoryTest(t, []time.Time{goodTime, badTime}, []string{historyTestGood, historyTestBad})
+
+	if _, rollbackErr := rollbackDatabase(fileName, goodTime.Format("20060102T150405Z")); rollbackErr != nil {
+		t.Fatalf("rollbackDatabase() error = %s", rollbackErr)
+	}
+
+	db, dbErr := loadDatabase(fileName)
+	if dbErr != nil {
+		t.Fatal(dbErr)
+	}
+	current, _ := db.LookupMAC("00:55:da:01:02:03")
+	if current.VendorName != "Shinko Technos co.,ltd." {
+		t.Fatalf("current vendor = %s after rollback", current.VendorName)
+	}
+
+	history, historyErr := loadHistory(fileName)
+	if historyErr != nil {
+		t.Fatal(historyErr)
+	}
+	if len(history) != 3 {
+		t.Fatalf("history has %d entries after rollback, want 3", len(history))
+	}
+
+	tests := []struct {
+		asOf time.Time
+		want string
+	}{
+		{goodTime, "Shinko Technos co.,ltd."},
+		{badTime, "IEEE Registration Authority"},
+		{time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), current.VendorName},
+	}
+	for _, tt := range tests {
+		if got := lookupVendorAsOf(t, fileName, tt.asOf); got != tt.want {
+			t.Errorf("vendor as of %s = %s, want %s", tt.asOf.Format(time.RFC3339), got, tt.want)
+		}
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	now := time.Now().UTC()
+	var timestamps []time.Time
+	var contents []string
+	for _, days := range []int{500, 400, 300, 200, 100, 50, 10} {
+		timestamps = append(timestamps, now.AddDate(0, 0, -days))
+		contents = append(contents, fmt.Sprintf("snapshot of %d days ago", days))
+	}
+	fileName := setupHistoryTest(t, timestamps, contents)
+
+	if storeErr := storeData(fileName, *bytes.NewBufferString(historyTestGood)); storeErr != nil {
+		t.Fatal(storeErr)
+	}
+	if historyErr := addHistorySnapshot(fileName, databaseMetadata{}); historyErr != nil {
+		t.Fatalf("addHistorySnapshot() error = %s", historyErr)
+	}
+
+	history, historyErr := loadHistory(fileName)
+	if historyErr != nil {
+		t.Fatal(historyErr)
+	}
+	if len(history) != 6 {
+		t.Fatalf("history has %d entries, want 6", len(history))
+	}
+	if !history[0].Timestamp.Equal(timestamps[2]) {
+		t.Errorf("oldest snapshot is from %s, want %s", history[0].Timestamp, timestamps[2])
+	}
+}
+
+func TestParseAsOf(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "2020-01-31", want: time.Date(2020, 1, 31, 23, 59, 59, 999999999, time.UTC)},
+		{value: "2020-01-31T08:15:00Z", want: time.Date(2020, 1, 31, 8, 15, 0, 0, time.UTC)},
+		{value: "2020-01-31T08:15:00+02:00", want: time.Date(2020, 1, 31, 6, 15, 0, 0, time.UTC)},
+		{value: "31.01.2020", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, parseErr := parseAsOf(tt.value)
+			if (parseErr != nil) != tt.wantErr {
+				t.Fatalf("parseAsOf() error = %v, wantErr %t", parseErr, tt.wantErr)
+			}
+			if !tt.wantErr && !got.Equal(tt.want) {
+				t.Errorf("parseAsOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
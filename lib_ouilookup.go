@@ -4,15 +4,20 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/gob"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
-	"regexp"
-	"sort"
+	"path/filepath"
 	"strings"
 	"time"
 
 	resty "github.com/go-resty/resty/v2"
+
+	oui "gitlab.com/rbrt-weiler/ouilookup/pkg/oui"
 )
 
 /*
@@ -25,46 +30,33 @@ import (
    ##       ##    ##        ########  ######
 */
 
-type ouiEntry struct {
-	VendorName    string   `json:"vendorName"`
-	VendorAddress []string `json:"vendorAddress"`
-}
-
-type ouiDatabase struct {
-	OUIDatabase map[string]ouiEntry `json:"ouiDatabase"`
-}
-
-func (db *ouiDatabase) ToText() string {
-	var lines []string
-
-	devMessage("Entering ouiDatabase.ToText()")
-
-	for oui, data := range db.OUIDatabase {
-		lines = append(lines, fmt.Sprintf("%s\t%s", oui, data.VendorName))
-	}
-
-	devMessage("Leaving ouiDatabase.ToText()")
-	return strings.Join(lines, "\n")
+// databaseMetadata is stored next to the local database and describes where
+// its content came from. Checked is the last time all sources were confirmed
+// to be unchanged.
+type databaseMetadata struct {
+	Sources []sourceMetadata `json:"sources"`
+	Checked time.Time        `json:"checked,omitempty"`
 }
 
-func (db *ouiDatabase) ToCSV() string {
-	var lines []string
-
-	devMessage("Entering ouiDatabase.ToCSV()")
-
-	for oui, data := range db.OUIDatabase {
-		lines = append(lines, fmt.Sprintf(`"%s","%s"`, oui, data.VendorName))
-	}
-
-	devMessage("Leaving ouiDatabase.ToCSV()")
-	return strings.Join(lines, "\n")
+// databaseCache is a parsed snapshot of a local database along with the
+// properties of the database file it was created from. Caches of another
+// Version are ignored.
+type databaseCache struct {
+	Version string
+	ModTime time.Time
+	Size    int64
+	SHA256  string
+	Entries map[string]oui.Entry
 }
 
-func (db *ouiDatabase) ToJSON() string {
-	devMessage("Entering ouiDatabase.ToJSON()")
-	json, _ := json.MarshalIndent(db, "", "    ")
-	devMessage("Leaving ouiDatabase.ToJSON()")
-	return string(json)
+// sourceMetadata holds the cache validators of a single downloaded source.
+// PartialValidator is set while a download of the source is incomplete.
+type sourceMetadata struct {
+	URL              string    `json:"url"`
+	ETag             string    `json:"etag,omitempty"`
+	LastModified     string    `json:"lastModified,omitempty"`
+	Downloaded       time.Time `json:"downloaded"`
+	PartialValidator string    `json:"partialValidator,omitempty"`
 }
 
 /*
@@ -77,41 +69,272 @@ func (db *ouiDatabase) ToJSON() string {
 ##       #### ######## ########    ##     ## ##     ## ##    ## ########  ######## #### ##    ##  ######
 */
 
-func fetchOnlineDatabase(url string) (bytes.Buffer, error) {
-	var buf bytes.Buffer
+// userDataDir returns the per-user data directory of the tool, following the
+// XDG Base Directory Specification.
+func userDataDir() string {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if !filepath.IsAbs(dataHome) {
+		homeDir, homeDirErr := os.UserHomeDir()
+		if homeDirErr != nil {
+			return ""
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	return filepath.Join(dataHome, dataDirName)
+}
+
+// databaseSearchPath returns the locations that are searched for a database,
+// in order of preference.
+func databaseSearchPath() (path []string) {
+	if dataDir := userDataDir(); dataDir != "" {
+		path = append(path, filepath.Join(dataDir, ouiDatabaseFile))
+	}
+	path = append(path, filepath.Join(systemDataDir, ouiDatabaseFile), filepath.Join(sharedDataDir, ouiDatabaseFile))
+	return
+}
+
+// defaultDatabaseFile returns the location new databases are written to: the
+// system location for root and the user location for everybody else.
+func defaultDatabaseFile() string {
+	dataDir := userDataDir()
+	if os.Geteuid() == 0 || dataDir == "" {
+		dataDir = systemDataDir
+	}
+	return filepath.Join(dataDir, ouiDatabaseFile)
+}
 
+// resolveDatabaseFile sets the database file if none was given. Commands that
+// only read the database use the first existing file of the search path,
+// commands that write it use the default location.
+func resolveDatabaseFile(forWriting bool) {
+	devMessage("Entering resolveDatabaseFile()")
+
+	if config.DatabaseFile != "" {
+		return
+	}
+	config.DatabaseFile = defaultDatabaseFile()
+	if !forWriting {
+		for _, fileName := range databaseSearchPath() {
+			if _, statErr := os.Stat(fileName); statErr == nil {
+				config.DatabaseFile = fileName
+				break
+			}
+		}
+	}
+	devMessage(fmt.Sprintf("Using database file %s", config.DatabaseFile))
+
+	devMessage("Leaving resolveDatabaseFile()")
+}
+
+func fetchOnlineDatabase(url string, previous sourceMetadata, partFile string) (buf bytes.Buffer, source sourceMetadata, notModified bool, err error) {
 	devMessage("Entering fetchOnlineDatabase()")
 
+	source = previous
+	source.URL = url
+
 	client := resty.New()
 	client.SetTimeout(time.Duration(config.Update.HTTPTimeoutSeconds) * time.Second)
-	resp, respErr := client.R().Get(url)
+	req := client.R().SetDoNotParseResponse(true)
+
+	var partSize int64
+	if partInfo, partInfoErr := os.Stat(partFile); partInfoErr == nil && previous.PartialValidator != "" {
+		partSize = partInfo.Size()
+		req.SetHeader("Range", fmt.Sprintf("bytes=%d-", partSize))
+		req.SetHeader("If-Range", previous.PartialValidator)
+		devMessage(fmt.Sprintf("Resuming download at byte %d", partSize))
+	} else {
+		if previous.ETag != "" {
+			req.SetHeader("If-None-Match", previous.ETag)
+		}
+		if previous.LastModified != "" {
+			req.SetHeader("If-Modified-Since", previous.LastModified)
+		}
+	}
+
+	resp, respErr := req.Get(url)
 	if respErr != nil {
-		return buf, respErr
+		err = respErr
+		return
 	}
+	body := resp.RawBody()
+	defer body.Close()
 	devMessage(fmt.Sprintf("Status Code   : %v", resp.StatusCode()))
-	devMessage(fmt.Sprintf("Response Size : %v", resp.Size()))
 
+	var partFlags int
+	switch resp.StatusCode() {
+	case http.StatusNotModified:
+		notModified = true
+		return
+	case http.StatusPartialContent:
+		if !strings.HasPrefix(resp.Header().Get("Content-Range"), fmt.Sprintf("bytes %d-", partSize)) {
+			err = fmt.Errorf("Unexpected Content-Range %s", resp.Header().Get("Content-Range"))
+			return
+		}
+		partFlags = os.O_WRONLY | os.O_APPEND
+		source.ETag = resp.Header().Get("ETag")
+		source.LastModified = resp.Header().Get("Last-Modified")
+	case http.StatusOK:
+		partFlags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		source.ETag = resp.Header().Get("ETag")
+		source.LastModified = resp.Header().Get("Last-Modified")
+		source.PartialValidator = source.ETag
+		if source.PartialValidator == "" {
+			source.PartialValidator = source.LastModified
+		}
+	default:
+		err = fmt.Errorf("Unexpected HTTP status %s", resp.Status())
+		return
+	}
+
+	partHandle, partErr := os.OpenFile(partFile, partFlags, 0644)
+	if partErr != nil {
+		err = fmt.Errorf("Could not open partial download: %s", partErr)
+		return
+	}
+	_, copyErr := io.Copy(partHandle, body)
+	closeErr := partHandle.Close()
+	if copyErr != nil {
+		err = fmt.Errorf("Download interrupted: %s", copyErr)
+		return
+	}
+	if closeErr != nil {
+		err = fmt.Errorf("Could not write partial download: %s", closeErr)
+		return
+	}
+
+	buf, err = loadData(partFile)
+	if err != nil {
+		return
+	}
+	os.Remove(partFile)
+	source.PartialValidator = ""
+	source.Downloaded = time.Now()
+
+	devMessage(fmt.Sprintf("Response Size : %v", buf.Len()))
 	devMessage("Leaving fetchOnlineDatabase()")
-	return *bytes.NewBuffer(resp.Body()), nil
+	return
 }
 
-func storeOnlineDatabase(url string, fileName string) error {
-	var database *bytes.Buffer
+// storeOnlineDatabase fetches all given URLs and stores them merged into
+// fileName. Validators of previous downloads are sent along, so nothing is
+// written if none of the sources has changed; in that case updated is false.
+func storeOnlineDatabase(urls []string, fileName string) (updated bool, err error) {
+	var onlineDatabase bytes.Buffer
 
 	devMessage("Entering storeOnlineDatabase()")
 
-	onlineDatabase, onlineDatabaseErr := fetchOnlineDatabase(url)
-	if onlineDatabaseErr != nil {
-		return fmt.Errorf("Error fetching online OUI database: %s", onlineDatabaseErr)
+	if dirErr := os.MkdirAll(filepath.Dir(fileName), 0755); dirErr != nil {
+		return false, fmt.Errorf("Could not create directory: %s", dirErr)
+	}
+	metadata, metadataErr := loadDatabaseMetadata(fileName)
+	if metadataErr != nil {
+		devMessage(fmt.Sprintf("Ignoring metadata: %s", metadataErr))
+	}
+	_, statErr := os.Stat(fileName)
+	previousSources := make(map[string]sourceMetadata)
+	for _, source := range metadata.Sources {
+		if statErr != nil {
+			// Without a local database there is nothing to validate against.
+			source.ETag = ""
+			source.LastModified = ""
+		}
+		previousSources[source.URL] = source
+	}
+
+	sources := make([]sourceMetadata, len(urls))
+	contents := make([]bytes.Buffer, len(urls))
+	unchanged := make([]bool, len(urls))
+	allUnchanged := true
+	for i, url := range urls {
+		var fetchErr error
+		contents[i], sources[i], unchanged[i], fetchErr = fetchOnlineDatabase(url, previousSources[url], partFileName(fileName, i))
+		if fetchErr != nil {
+			if sources[i].PartialValidator != "" {
+				// Remember how to resume the download, but keep the validators
+				// that describe the current local database.
+				interrupted := previousSources[url]
+				interrupted.URL = url
+				interrupted.PartialValidator = sources[i].PartialValidator
+				metadata.Sources = append(withoutSource(metadata.Sources, url), interrupted)
+				storeDatabaseMetadata(fileName, metadata)
+			}
+			return false, fmt.Errorf("Error fetching online OUI database %s: %s", url, fetchErr)
+		}
+		allUnchanged = allUnchanged && unchanged[i]
+	}
+	if allUnchanged && sourcesMatch(metadata.Sources, urls) {
+		devMessage("All sources are unchanged")
+		metadata.Checked = time.Now()
+		if metadataErr := storeDatabaseMetadata(fileName, metadata); metadataErr != nil {
+			return false, fmt.Errorf("Error storing database metadata: %s", metadataErr)
+		}
+		return false, nil
+	}
+
+	for i, url := range urls {
+		if unchanged[i] {
+			// The local database only exists merged, so unchanged sources have
+			// to be fetched again if any other source has changed.
+			var fetchErr error
+			contents[i], sources[i], _, fetchErr = fetchOnlineDatabase(url, sourceMetadata{}, partFileName(fileName, i))
+			if fetchErr != nil {
+				return false, fmt.Errorf("Error fetching online OUI database %s: %s", url, fetchErr)
+			}
+		}
+		onlineDatabase.Write(contents[i].Bytes())
+		onlineDatabase.WriteString("\n\n")
 	}
-	database = &onlineDatabase
 
+	storeErr := storeDatabase(fileName, onlineDatabase)
+	if storeErr != nil {
+		return false, storeErr
+	}
+
+	metadata.Sources = sources
+	metadata.Checked = time.Now()
+	if metadataErr := storeDatabaseMetadata(fileName, metadata); metadataErr != nil {
+		return true, fmt.Errorf("Error storing database metadata: %s", metadataErr)
+	}
+	if historyErr := addHistorySnapshot(fileName, metadata); historyErr != nil {
+		stdErr.Printf("Warning: Could not record database history: %s\n", historyErr)
+	}
+
+	devMessage("Leaving storeOnlineDatabase()")
+	return true, nil
+}
+
+// storeDatabase validates uncompressed database content and stores it in the
+// format implied by fileName.
+func storeDatabase(fileName string, content bytes.Buffer) error {
+	var database *bytes.Buffer
+
+	devMessage("Entering storeDatabase()")
+
+	parsedDatabase, parsedDatabaseErr := oui.Parse(bytes.NewReader(content.Bytes()))
+	if parsedDatabaseErr != nil {
+		return fmt.Errorf("Error parsing OUI database: %s", parsedDatabaseErr)
+	}
+	if parsedDatabase.Len() == 0 {
+		return fmt.Errorf("OUI database does not contain any entries")
+	}
+	shrinkErr := checkDatabaseShrink(fileName, parsedDatabase.Len())
+	if shrinkErr != nil {
+		return shrinkErr
+	}
+
+	database = &content
 	if strings.HasSuffix(fileName, ".gz") {
-		compressedData, compressedDataErr := compressData(onlineDatabase)
+		compressedData, compressedDataErr := compressData(content)
 		if compressedDataErr != nil {
 			return fmt.Errorf("Error compressing OUI database: %s", compressedDataErr)
 		}
 		database = &compressedData
+	} else if strings.HasSuffix(fileName, binaryDatabaseSuffix) {
+		binaryData, binaryDataErr := parsedDatabase.MarshalBinary()
+		if binaryDataErr != nil {
+			return fmt.Errorf("Error encoding binary OUI database: %s", binaryDataErr)
+		}
+		database = bytes.NewBuffer(binaryData)
 	}
 
 	storeErr := storeData(fileName, *database)
@@ -119,17 +342,117 @@ func storeOnlineDatabase(url string, fileName string) error {
 		return fmt.Errorf("Error storing local OUI database: %s", storeErr)
 	}
 
-	devMessage("Leaving storeOnlineDatabase()")
+	devMessage("Leaving storeDatabase()")
+	return nil
+}
+
+// checkDatabaseShrink rejects a new database that has drastically fewer
+// entries than the current local database, unless forced to accept it.
+func checkDatabaseShrink(fileName string, newLen int) error {
+	devMessage("Entering checkDatabaseShrink()")
+
+	if config.Update.Force {
+		return nil
+	}
+	if _, statErr := os.Stat(fileName); statErr != nil {
+		return nil
+	}
+	currentDatabase, currentDatabaseErr := loadDatabase(fileName)
+	if currentDatabaseErr != nil {
+		devMessage(fmt.Sprintf("Not comparing against current database: %s", currentDatabaseErr))
+		return nil
+	}
+	currentLen := currentDatabase.Len()
+	if newLen*100 < currentLen*(100-int(config.Update.MaxShrinkPercent)) {
+		return fmt.Errorf("New OUI database has %d entries, the current one %d; use --force to replace it anyway", newLen, currentLen)
+	}
+
+	devMessage("Leaving checkDatabaseShrink()")
 	return nil
 }
 
+func withoutSource(sources []sourceMetadata, url string) (filtered []sourceMetadata) {
+	for _, source := range sources {
+		if source.URL != url {
+			filtered = append(filtered, source)
+		}
+	}
+	return
+}
+
+// sourcesMatch reports whether the local database was downloaded from exactly
+// the given URLs.
+func sourcesMatch(sources []sourceMetadata, urls []string) bool {
+	downloaded := make(map[string]bool)
+	for _, source := range sources {
+		if !source.Downloaded.IsZero() {
+			downloaded[source.URL] = true
+		}
+	}
+	if len(downloaded) != len(sources) {
+		return false
+	}
+	for _, url := range urls {
+		if !downloaded[url] {
+			return false
+		}
+		delete(downloaded, url)
+	}
+
+	return len(downloaded) == 0
+}
+
+func partFileName(fileName string, index int) string {
+	return fmt.Sprintf("%s.%d.part", fileName, index)
+}
+
+func metadataFileName(fileName string) string {
+	return fileName + ".meta.json"
+}
+
+func loadDatabaseMetadata(fileName string) (metadata databaseMetadata, err error) {
+	devMessage("Entering loadDatabaseMetadata()")
+
+	content, contentErr := loadData(metadataFileName(fileName))
+	if contentErr != nil {
+		err = contentErr
+		return
+	}
+	if jsonErr := json.Unmarshal(content.Bytes(), &metadata); jsonErr != nil {
+		err = fmt.Errorf("Could not parse metadata: %s", jsonErr)
+		return
+	}
+
+	devMessage("Leaving loadDatabaseMetadata()")
+	return
+}
+
+func storeDatabaseMetadata(fileName string, metadata databaseMetadata) error {
+	devMessage("Entering storeDatabaseMetadata()")
+
+	content, contentErr := json.MarshalIndent(metadata, "", "    ")
+	if contentErr != nil {
+		return fmt.Errorf("Could not encode metadata: %s", contentErr)
+	}
+
+	devMessage("Leaving storeDatabaseMetadata()")
+	return storeData(metadataFileName(fileName), *bytes.NewBuffer(content))
+}
+
+// storeData writes content to a temporary file next to fileName and renames
+// it afterwards, so fileName is either replaced completely or not at all.
 func storeData(fileName string, content bytes.Buffer) error {
 	devMessage("Entering storeData()")
 
-	fileHandle, fileErr := os.Create(fileName)
+	if dirErr := os.MkdirAll(filepath.Dir(fileName), 0755); dirErr != nil {
+		return fmt.Errorf("Could not create directory: %s", dirErr)
+	}
+	fileHandle, fileErr := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".tmp-*")
 	if fileErr != nil {
 		return fmt.Errorf("Could not create outfile: %s", fileErr)
 	}
+	tempFileName := fileHandle.Name()
+	defer os.Remove(tempFileName)
 	defer fileHandle.Close()
 
 	fileWriter := bufio.NewWriter(fileHandle)
@@ -142,6 +465,22 @@ func storeData(fileName string, content bytes.Buffer) error {
 	if flushErr != nil {
 		return fmt.Errorf("Could not flush file buffer: %s", flushErr)
 	}
+	syncErr := fileHandle.Sync()
+	if syncErr != nil {
+		return fmt.Errorf("Could not sync outfile: %s", syncErr)
+	}
+	closeErr := fileHandle.Close()
+	if closeErr != nil {
+		return fmt.Errorf("Could not close outfile: %s", closeErr)
+	}
+	chmodErr := os.Chmod(tempFileName, 0644)
+	if chmodErr != nil {
+		return fmt.Errorf("Could not set permissions of outfile: %s", chmodErr)
+	}
+	renameErr := os.Rename(tempFileName, fileName)
+	if renameErr != nil {
+		return fmt.Errorf("Could not replace outfile: %s", renameErr)
+	}
 
 	devMessage("Leaving storeData()")
 	return nil
@@ -162,25 +501,6 @@ func loadData(fileName string) (bytes.Buffer, error) {
 	return retVal, nil
 }
 
-func decompressData(content bytes.Buffer) (bytes.Buffer, error) {
-	var buf bytes.Buffer
-
-	devMessage("Entering decompressData()")
-
-	reader, readerErr := gzip.NewReader(&content)
-	if readerErr != nil {
-		return buf, fmt.Errorf("Could not read compressed data: %s", readerErr)
-	}
-
-	_, bufErr := buf.ReadFrom(reader)
-	if bufErr != nil {
-		return buf, fmt.Errorf("Could not read compressed data: %s", bufErr)
-	}
-
-	devMessage("Leaving decompressData()")
-	return buf, nil
-}
-
 func compressData(content bytes.Buffer) (bytes.Buffer, error) {
 	var buf bytes.Buffer
 
@@ -190,7 +510,8 @@ func compressData(content bytes.Buffer) (bytes.Buffer, error) {
 	if gzipWriterErr != nil {
 		return buf, fmt.Errorf("Could not create gzip stream: %s", gzipWriterErr)
 	}
-	gzipWriter.ModTime = time.Now()
+	// No modification time is set, so the same content always results in the
+	// same file and identical databases are recognized by their hash.
 	gzipWriter.Comment = fmt.Sprintf("created with %s", toolID)
 	_, writeErr := gzipWriter.Write(content.Bytes())
 	if writeErr != nil {
@@ -219,176 +540,162 @@ func compressData(content bytes.Buffer) (bytes.Buffer, error) {
 ########  ##     ##    ##    ##     ## ########  ##     ##  ######  ########    ##     ## ##     ## ##    ## ########  ######## #### ##    ##  ######
 */
 
-func loadRawDatabase(fileName string) (db bytes.Buffer, err error) {
-	devMessage("Entering loadRawDatabase()")
+// loadDatabase loads and parses a local database. Parsed databases are cached
+// in a snapshot next to the database file, which is used as long as the
+// modification time and size or the hash of the database file are unchanged.
+func loadDatabase(fileName string) (db *oui.Database, err error) {
+	var cache databaseCache
+	var cacheErr error
 
-	db, err = loadData(fileName)
-	if err != nil {
-		return db, fmt.Errorf("Could not load database: %s", err)
-	}
+	devMessage("Entering loadDatabase()")
 
-	if strings.HasSuffix(fileName, ".gz") {
-		db, err = decompressData(db)
-		if err != nil {
-			return db, fmt.Errorf("Could not decompress database: %s", err)
+	info, infoErr := os.Stat(fileName)
+	if infoErr != nil {
+		if os.IsNotExist(infoErr) && len(embeddedDatabase) > 0 {
+			return loadEmbeddedDatabase(fileName)
 		}
+		return db, fmt.Errorf("Error reading local OUI database: %s", infoErr)
 	}
-
-	devMessage("Leaving loadRawDatabase()")
-	return
-}
-
-func parseRawDatabase(content bytes.Buffer) (ouiDB ouiDatabase, err error) {
-	var reBase16 = regexp.MustCompile(`^(.+?)\s+\(base 16\)\s+(.+?)$`)
-	var inVendorBlock bool
-	var vendorOUI string
-	var vendorName string
-	var vendorAddress []string
-
-	devMessage("Entering parseRawDatabase()")
-
-	if ouiDB.OUIDatabase == nil {
-		ouiDB.OUIDatabase = make(map[string]ouiEntry)
-	}
-
-	inVendorBlock = false
-	fs := bufio.NewScanner(bytes.NewReader(content.Bytes()))
-	for fs.Scan() {
-		if inVendorBlock {
-			trimmed := strings.TrimSpace(fs.Text())
-			if trimmed == "" {
-				ouiDB.OUIDatabase[vendorOUI] = ouiEntry{VendorName: vendorName, VendorAddress: vendorAddress}
-				inVendorBlock = false
-				vendorAddress = []string{}
-				continue
-			}
-			vendorAddress = append(vendorAddress, trimmed)
-		} else if reBase16.Match(fs.Bytes()) {
-			inVendorBlock = true
-			ouiData := reBase16.FindStringSubmatch(fs.Text())
-			vendorOUI = strings.ToLower(ouiData[1])
-			vendorName = ouiData[2]
+	if !config.NoCache {
+		cache, cacheErr = loadDatabaseCache(fileName)
+		if cacheErr == nil && cache.ModTime.Equal(info.ModTime()) && cache.Size == info.Size() {
+			devMessage("Using cached database")
+			return oui.NewDatabase(cache.Entries), nil
 		}
 	}
 
-	devMessage("Leaving parseRawDatabase()")
-	return
-}
-
-func ouiToVendorDatabase(ouiDB ouiDatabase) (vendorDB map[string][]string, err error) {
-	devMessage("Entering ouiToVendorDatabase()")
-	if vendorDB == nil {
-		vendorDB = make(map[string][]string)
+	rawDB, rawDBErr := loadData(fileName)
+	if rawDBErr != nil {
+		return db, fmt.Errorf("Error reading local OUI database: %s", rawDBErr)
 	}
-	for oui, data := range ouiDB.OUIDatabase {
-		vendorDB[data.VendorName] = append(vendorDB[data.VendorName], oui)
+	hash := fmt.Sprintf("%x", sha256.Sum256(rawDB.Bytes()))
+	if !config.NoCache && cacheErr == nil && cache.SHA256 == hash {
+		devMessage("Using cached database with unchanged hash")
+		db = oui.NewDatabase(cache.Entries)
+	} else {
+		_, binaryErr := oui.ReadBinary(rawDB.Bytes())
+		db, err = oui.Load(&rawDB)
+		if err != nil {
+			return db, fmt.Errorf("Error parsing local OUI database: %s", err)
+		}
+		if binaryErr == nil {
+			// Binary databases load fast enough without a cache.
+			return
+		}
 	}
-	for vendor := range vendorDB {
-		sort.Strings(vendorDB[vendor])
+
+	if !config.NoCache {
+		cache = databaseCache{Version: databaseCacheVersion, ModTime: info.ModTime(), Size: info.Size(), SHA256: hash, Entries: db.Entries()}
+		if cacheErr := storeDatabaseCache(fileName, cache); cacheErr != nil {
+			devMessage(fmt.Sprintf("Could not store database cache: %s", cacheErr))
+		}
 	}
-	devMessage("Leaving ouiToVendorDatabase()")
+
+	devMessage("Leaving loadDatabase()")
 	return
 }
 
-func loadDatabase(fileName string) (db ouiDatabase, err error) {
-	devMessage("Entering loadDatabase()")
+// parseDatabaseFile loads and parses an arbitrary database file, without
+// using or creating a cache and without falling back to the embedded database.
+func parseDatabaseFile(fileName string) (db *oui.Database, err error) {
+	devMessage("Entering parseDatabaseFile()")
 
-	rawDB, rawDBErr := loadRawDatabase(config.DatabaseFile)
+	rawDB, rawDBErr := loadData(fileName)
 	if rawDBErr != nil {
-		return db, fmt.Errorf("Error reading local OUI database: %s", rawDBErr)
+		return db, fmt.Errorf("Error reading OUI database %s: %s", fileName, rawDBErr)
 	}
-	db, err = parseRawDatabase(rawDB)
+	db, err = oui.Load(&rawDB)
 	if err != nil {
-		return db, fmt.Errorf("Error parsing local OUI database: %s", err)
+		return db, fmt.Errorf("Error parsing OUI database %s: %s", fileName, err)
 	}
 
-	devMessage("Leaving loadDatabase()")
+	devMessage("Leaving parseDatabaseFile()")
 	return
 }
 
-/*
-#### ##    ## ########  ##     ## ########    ##     ##    ###    ##    ## ########  ##       #### ##    ##  ######
- ##  ###   ## ##     ## ##     ##    ##       ##     ##   ## ##   ###   ## ##     ## ##        ##  ###   ## ##    ##
- ##  ####  ## ##     ## ##     ##    ##       ##     ##  ##   ##  ####  ## ##     ## ##        ##  ####  ## ##
- ##  ## ## ## ########  ##     ##    ##       ######### ##     ## ## ## ## ##     ## ##        ##  ## ## ## ##   ####
- ##  ##  #### ##        ##     ##    ##       ##     ## ######### ##  #### ##     ## ##        ##  ##  #### ##    ##
- ##  ##   ### ##        ##     ##    ##       ##     ## ##     ## ##   ### ##     ## ##        ##  ##   ### ##    ##
-#### ##    ## ##         #######     ##       ##     ## ##     ## ##    ## ########  ######## #### ##    ##  ######
-*/
+// loadEmbeddedDatabase parses the database snapshot embedded into the binary
+// and warns about its age.
+func loadEmbeddedDatabase(fileName string) (db *oui.Database, err error) {
+	devMessage("Entering loadEmbeddedDatabase()")
 
-func filterHexChars(r rune) rune {
-	devMessage("Entering filterHexChars()")
+	age := "of unknown age"
+	if gzipReader, gzipErr := gzip.NewReader(bytes.NewReader(embeddedDatabase)); gzipErr == nil {
+		if !gzipReader.ModTime.IsZero() {
+			age = fmt.Sprintf("from %s, %d days old", gzipReader.ModTime.Format("2006-01-02"), int(time.Since(gzipReader.ModTime).Hours()/24))
+		}
+		gzipReader.Close()
+	}
+	stdErr.Printf("Warning: Local OUI database %s not found, using embedded snapshot %s. Run update to fetch a current database.\n", fileName, age)
 
-	switch {
-	case r >= '0' && r <= '9':
-		return r
-	case r >= 'A' && r <= 'F':
-		return r
-	case r >= 'a' && r <= 'f':
-		return r
+	db, err = oui.Load(bytes.NewReader(embeddedDatabase))
+	if err != nil {
+		return db, fmt.Errorf("Error parsing embedded OUI database: %s", err)
 	}
 
-	devMessage("Leaving filterHexChars()")
-	return -1
+	devMessage("Leaving loadEmbeddedDatabase()")
+	return
 }
 
-func isValidMAC(mac string) bool {
-	var reFullMAC = regexp.MustCompile(`^([0-9A-Fa-f]{2}[-:]?){5}[0-9A-Fa-f]{2}$`)
-	var reOUIOnly = regexp.MustCompile(`^([0-9A-Fa-f]{2}[-:]?){2}[0-9A-Fa-f]{2}$`)
+func cacheFileName(fileName string) string {
+	return fileName + ".cache"
+}
 
-	devMessage("Entering isValidMAC()")
+func loadDatabaseCache(fileName string) (cache databaseCache, err error) {
+	devMessage("Entering loadDatabaseCache()")
 
-	if reFullMAC.Match([]byte(mac)) {
-		return true
+	content, contentErr := loadData(cacheFileName(fileName))
+	if contentErr != nil {
+		err = contentErr
+		return
+	}
+	if gobErr := gob.NewDecoder(&content).Decode(&cache); gobErr != nil {
+		err = fmt.Errorf("Could not decode cache: %s", gobErr)
+		return
 	}
-	if reOUIOnly.Match([]byte(mac)) {
-		return true
+	if cache.Version != databaseCacheVersion {
+		err = fmt.Errorf("Cache version %s does not match %s", cache.Version, databaseCacheVersion)
+		return
 	}
 
-	devMessage("Leaving isValidMAC()")
-	return false
+	devMessage("Leaving loadDatabaseCache()")
+	return
 }
 
-func extractOUI(mac string) (oui string, err error) {
-	devMessage("Entering extractOUI()")
+func storeDatabaseCache(fileName string, cache databaseCache) error {
+	var content bytes.Buffer
 
-	hexOnly := strings.Map(filterHexChars, mac)
+	devMessage("Entering storeDatabaseCache()")
 
-	if len(hexOnly) < 6 {
-		err = fmt.Errorf("Not enough characters to extract an OUI")
-		return
+	if gobErr := gob.NewEncoder(&content).Encode(cache); gobErr != nil {
+		return fmt.Errorf("Could not encode cache: %s", gobErr)
 	}
-	oui = hexOnly[:6]
 
-	devMessage("Leaving extractOUI()")
-	return
+	devMessage("Leaving storeDatabaseCache()")
+	return storeData(cacheFileName(fileName), content)
 }
 
-func normalizeMAC(mac string) (normalizedMAC string, err error) {
-	var normalized []rune
-	var charCount uint
-
-	devMessage("Entering normalizeMAC()")
+// loadResolver returns a resolver for MAC lookups. Databases in binary format
+// are memory mapped and queried in place, all others are loaded completely.
+// The returned function releases the resolver.
+func loadResolver(fileName string) (resolver oui.Resolver, release func(), err error) {
+	devMessage("Entering loadResolver()")
 
-	mac = strings.Map(filterHexChars, mac)
-	if len(mac) > 12 {
-		err = fmt.Errorf("MAC too long")
+	release = func() {}
+	if _, statErr := os.Stat(fileName); os.IsNotExist(statErr) {
+		resolver, err = loadDatabase(fileName)
 		return
 	}
-	for len(mac) < 12 {
-		mac = mac + "0"
+	bdb, bdbErr := oui.OpenBinary(fileName)
+	if bdbErr == nil {
+		devMessage("Using binary database")
+		return bdb, func() { bdb.Close() }, nil
 	}
-
-	charCount = 0
-	for _, c := range mac {
-		charCount++
-		normalized = append(normalized, c)
-		if charCount%2 == 0 {
-			normalized = append(normalized, ':')
-		}
+	if bdbErr != oui.ErrNotBinary {
+		err = fmt.Errorf("Error reading local OUI database: %s", bdbErr)
+		return
 	}
-	normalizedMAC = strings.Trim(string(normalized), ":")
+	resolver, err = loadDatabase(fileName)
 
-	devMessage("Leaving normalizeMAC()")
+	devMessage("Leaving loadResolver()")
 	return
 }
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSourcesMatch(t *testing.T) {
+	downloaded := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ouiSource := sourceMetadata{URL: "https://example.com/oui.txt", Downloaded: downloaded}
+	mamSource := sourceMetadata{URL: "https://example.com/mam.txt", Downloaded: downloaded}
+	interrupted := sourceMetadata{URL: "https://example.com/cid.txt", PartialValidator: `"etag"`}
+
+	tests := []struct {
+		name    string
+		sources []sourceMetadata
+		urls    []string
+		want    bool
+	}{
+		{"same URLs", []sourceMetadata{ouiSource, mamSource}, []string{ouiSource.URL, mamSource.URL}, true},
+		{"different order", []sourceMetadata{mamSource, ouiSource}, []string{ouiSource.URL, mamSource.URL}, true},
+		{"URL added", []sourceMetadata{ouiSource}, []string{ouiSource.URL, mamSource.URL}, false},
+		{"URL removed", []sourceMetadata{ouiSource, mamSource}, []string{ouiSource.URL}, false},
+		{"URL replaced", []sourceMetadata{ouiSource, mamSource}, []string{ouiSource.URL, interrupted.URL}, false},
+		{"interrupted download", []sourceMetadata{ouiSource, interrupted}, []string{ouiSource.URL, interrupted.URL}, false},
+		{"no metadata", nil, []string{ouiSource.URL}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sourcesMatch(tt.sources, tt.urls); got != tt.want {
+				t.Errorf("sourcesMatch() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+/*
+######## ##    ## ########  ########  ######
+   ##     ##  ##  ##     ## ##       ##    ##
+   ##      ####   ##     ## ##       ##
+   ##       ##    ########  ######    ######
+   ##       ##    ##        ##             ##
+   ##       ##    ##        ##       ##    ##
+   ##       ##    ##        ########  ######
+*/
+
+// outputRecord is a single result of the mac and vendor commands. Failed
+// lookups are reported as records with Error set.
+type outputRecord struct {
+	Input         string   `json:"input" yaml:"input"`
+	MAC           string   `json:"mac,omitempty" yaml:"mac,omitempty"`
+	OUI           string   `json:"oui,omitempty" yaml:"oui,omitempty"`
+	PrefixLength  int      `json:"prefixLength,omitempty" yaml:"prefixLength,omitempty"`
+	Registry      string   `json:"registry,omitempty" yaml:"registry,omitempty"`
+	VendorName    string   `json:"vendorName,omitempty" yaml:"vendorName,omitempty"`
+	VendorAddress []string `json:"vendorAddress,omitempty" yaml:"vendorAddress,omitempty"`
+	Error         string   `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+type recordWriter interface {
+	Write(record outputRecord) error
+	Flush() error
+	Close() error
+}
+
+type textRecordWriter struct {
+	out    *bufio.Writer
+	format func(outputRecord) string
+}
+
+type jsonRecordWriter struct {
+	out   *bufio.Writer
+	lines bool
+	count int
+}
+
+type separatedRecordWriter struct {
+	out           *bufio.Writer
+	csvWriter     *csv.Writer
+	headerWritten bool
+}
+
+type yamlRecordWriter struct {
+	out *bufio.Writer
+}
+
+/*
+ ######   #######  ##    ##  ######  ########    ###    ##    ## ########  ######
+##    ## ##     ## ###   ## ##    ##    ##      ## ##   ###   ##    ##    ##    ##
+##       ##     ## ####  ## ##          ##     ##   ##  ####  ##    ##    ##
+##       ##     ## ## ## ##  ######     ##    ##     ## ## ## ##    ##     ######
+##       ##     ## ##  ####       ##    ##    ######### ##  ####    ##          ##
+##    ## ##     ## ##   ### ##    ##    ##    ##     ## ##   ###    ##    ##    ##
+ ######   #######  ##    ##  ######     ##    ##     ## ##    ##    ##     ######
+*/
+
+const (
+	// Valid values for --output of the mac and vendor commands
+	outputFormats string = "text, json, ndjson, csv, tsv, yaml"
+)
+
+var (
+	outputHeader = []string{"input", "mac", "oui", "prefixLength", "registry", "vendorName", "vendorAddress", "error"}
+)
+
+/*
+######## ##     ## ##    ##  ######   ######
+##       ##     ## ###   ## ##    ## ##    ##
+##       ##     ## ####  ## ##       ##
+######   ##     ## ## ## ## ##        ######
+##       ##     ## ##  #### ##             ##
+##       ##     ## ##   ### ##    ## ##    ##
+##        #######  ##    ##  ######   ######
+*/
+
+// newRecordWriter returns a writer for the given output format. Text output
+// is rendered with textFormat; errors are printed as warnings on stderr.
+func newRecordWriter(format string, out *bufio.Writer, textFormat func(outputRecord) string) (recordWriter, error) {
+	devMessage("Entering newRecordWriter()")
+
+	switch format {
+	case "text":
+		return &textRecordWriter{out: out, format: textFormat}, nil
+	case "json":
+		return &jsonRecordWriter{out: out}, nil
+	case "ndjson":
+		return &jsonRecordWriter{out: out, lines: true}, nil
+	case "csv", "tsv":
+		csvWriter := csv.NewWriter(out)
+		if format == "tsv" {
+			csvWriter.Comma = '\t'
+		}
+		return &separatedRecordWriter{out: out, csvWriter: csvWriter}, nil
+	case "yaml":
+		return &yamlRecordWriter{out: out}, nil
+	}
+
+	devMessage("Leaving newRecordWriter()")
+	return nil, fmt.Errorf("Unsupported output format %s, use one of %s", format, outputFormats)
+}
+
+func (tw *textRecordWriter) Write(record outputRecord) error {
+	if record.Error != "" {
+		tw.out.Flush()
+		stdErr.Printf("Warning: %s\n", record.Error)
+		return nil
+	}
+	_, err := tw.out.WriteString(tw.format(record))
+	return err
+}
+
+func (tw *textRecordWriter) Flush() error {
+	return tw.out.Flush()
+}
+
+func (tw *textRecordWriter) Close() error {
+	return tw.out.Flush()
+}
+
+func (jw *jsonRecordWriter) Write(record outputRecord) error {
+	encoded, encodedErr := json.Marshal(record)
+	if encodedErr != nil {
+		return encodedErr
+	}
+	if !jw.lines {
+		if jw.count == 0 {
+			jw.out.WriteString("[\n")
+		} else {
+			jw.out.WriteString(",\n")
+		}
+	}
+	jw.count++
+	jw.out.Write(encoded)
+	if jw.lines {
+		jw.out.WriteString("\n")
+	}
+	return nil
+}
+
+func (jw *jsonRecordWriter) Flush() error {
+	return jw.out.Flush()
+}
+
+func (jw *jsonRecordWriter) Close() error {
+	if !jw.lines {
+		if jw.count == 0 {
+			jw.out.WriteString("[")
+		}
+		jw.out.WriteString("\n]\n")
+	}
+	return jw.out.Flush()
+}
+
+func (sw *separatedRecordWriter) Write(record outputRecord) error {
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	prefixLength := ""
+	if record.PrefixLength > 0 {
+		prefixLength = strconv.Itoa(record.PrefixLength)
+	}
+	return sw.csvWriter.Write([]string{
+		record.Input,
+		record.MAC,
+		record.OUI,
+		prefixLength,
+		record.Registry,
+		record.VendorName,
+		strings.Join(record.VendorAddress, ", "),
+		record.Error,
+	})
+}
+
+func (sw *separatedRecordWriter) writeHeader() error {
+	if sw.headerWritten {
+		return nil
+	}
+	sw.headerWritten = true
+	return sw.csvWriter.Write(outputHeader)
+}
+
+func (sw *separatedRecordWriter) Flush() error {
+	sw.csvWriter.Flush()
+	if err := sw.csvWriter.Error(); err != nil {
+		return err
+	}
+	return sw.out.Flush()
+}
+
+func (sw *separatedRecordWriter) Close() error {
+	if err := sw.writeHeader(); err != nil {
+		return err
+	}
+	return sw.Flush()
+}
+
+func (yw *yamlRecordWriter) Write(record outputRecord) error {
+	encoded, encodedErr := yaml.Marshal([]outputRecord{record})
+	if encodedErr != nil {
+		return encodedErr
+	}
+	_, err := yw.out.Write(encoded)
+	return err
+}
+
+func (yw *yamlRecordWriter) Flush() error {
+	return yw.out.Flush()
+}
+
+func (yw *yamlRecordWriter) Close() error {
+	return yw.out.Flush()
+}
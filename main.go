@@ -14,9 +14,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	cobra "github.com/spf13/cobra"
+	pflag "github.com/spf13/pflag"
 	envordef "gitlab.com/rbrt-weiler/go-module-envordef"
 )
 
@@ -31,17 +33,44 @@ import (
 */
 
 type appConfig struct {
-	DatabaseFile string
+	ConfigFile   string `yaml:"-"`
+	DatabaseFile string `yaml:"dbfile"`
+	NoCache      bool   `yaml:"nocache"`
 	Update       struct {
-		DatabaseURL        string
-		HTTPTimeoutSeconds uint
-	}
+		DatabaseURLs       []string `yaml:"dburls"`
+		HTTPTimeoutSeconds uint     `yaml:"httptimeout"`
+		MaxShrinkPercent   uint     `yaml:"maxshrink"`
+		HistorySize        uint     `yaml:"history"`
+		HistoryDays        uint     `yaml:"historydays"`
+		Force              bool     `yaml:"-"`
+		ShowDiff           bool     `yaml:"-"`
+	} `yaml:"update"`
 	Export struct {
-		OutputFormat string
-	}
+		OutputFormat string `yaml:"format"`
+	} `yaml:"export"`
+	MAC struct {
+		InputFile string `yaml:"-"`
+		AsOf      string `yaml:"-"`
+	} `yaml:"-"`
+	Output struct {
+		Format       string `yaml:"format"`
+		ReportFormat string `yaml:"-"`
+	} `yaml:"output"`
+	Vendor struct {
+		MatchMode   string `yaml:"match"`
+		Group       bool   `yaml:"group"`
+		AliasesFile string `yaml:"aliases"`
+	} `yaml:"vendor"`
+	Info struct {
+		MaxAgeDays uint `yaml:"maxage"`
+	} `yaml:"info"`
 	Server struct {
-		HTTPPort uint
-	}
+		ListenAddress         string `yaml:"address"`
+		HTTPPort              uint   `yaml:"port"`
+		WatchIntervalSeconds  uint   `yaml:"watchinterval"`
+		UpdateIntervalMinutes uint   `yaml:"updateinterval"`
+		RemoteAdmin           bool   `yaml:"remoteadmin"`
+	} `yaml:"server"`
 }
 
 /*
@@ -67,15 +96,45 @@ const (
 	// Error codes
 	errSuccess         int = 0
 	errMissingArgs     int = 1
+	errConfigFile      int = 5
 	errDatabaseUpdate  int = 10
 	errDatabaseLoad    int = 15
 	errDatabaseParse   int = 16
 	errDatabaseConvert int = 17
+	errDatabaseAge     int = 18
+	errHistory         int = 19
 	errExportFormat    int = 20
+	errOutputFormat    int = 21
+	errMatchMode       int = 22
+	errAliasesFile     int = 23
+	errInputRead       int = 25
+
+	// Databases stored with this suffix use the binary format
+	binaryDatabaseSuffix string = ".bin"
+
+	// Lower limit of the interval of periodic updates in the server
+	minUpdateIntervalMinutes uint = 60
+
+	// Version of parsed database caches; increase whenever parsing changes
+	databaseCacheVersion string = toolVersion + "/3"
+
+	// Configuration files looked up in the user and system config directories
+	configFileName  string = "config.yaml"
+	aliasesFileName string = "aliases.yaml"
+	systemConfigDir string = "/etc/" + dataDirName
+
+	// Directories searched for the database besides the user data directory
+	dataDirName   string = "ouilookup"
+	systemDataDir string = "/var/lib/" + dataDirName
+	sharedDataDir string = "/usr/share/" + dataDirName
 
 	// Hardcoded defaults as fallbacks
 	ouiDatabaseFile string = "oui.txt.gz"
-	ouiDatabaseURL  string = "http://standards-oui.ieee.org/oui.txt"
+	ouiDatabaseURLs string = "http://standards-oui.ieee.org/oui/oui.txt," +
+		"http://standards-oui.ieee.org/oui28/mam.txt," +
+		"http://standards-oui.ieee.org/oui36/oui36.txt," +
+		"http://standards-oui.ieee.org/iab/iab.txt," +
+		"http://standards-oui.ieee.org/cid/cid.txt"
 )
 
 /*
@@ -92,6 +151,12 @@ var (
 	config  appConfig
 	devMode bool = false
 	stdErr       = log.New(os.Stderr, "", 0)
+
+	// Alternative spellings accepted for flags
+	flagAliases = map[string]string{
+		"update-interval": "updateinterval",
+		"as-of":           "asof",
+	}
 )
 
 /*
@@ -119,6 +184,26 @@ func devMessage(message string) {
 	}
 }
 
+// normalizeFlagName maps the alternative spellings in flagAliases to the
+// names the flags are defined with.
+func normalizeFlagName(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if alias, found := flagAliases[name]; found {
+		name = alias
+	}
+	return pflag.NormalizedName(name)
+}
+
+// reportFormat returns format if the commands info, diff and history list
+// support it and text otherwise, so a default output format meant for mac and
+// vendor does not break these commands.
+func reportFormat(format string) string {
+	switch format {
+	case "text", "json", "yaml":
+		return format
+	}
+	return "text"
+}
+
 func sanitizeArguments() {
 	devMessage("Entering sanitizeArguments()")
 	if config.Update.HTTPTimeoutSeconds < 5 {
@@ -126,9 +211,16 @@ func sanitizeArguments() {
 	} else if config.Update.HTTPTimeoutSeconds > 300 {
 		config.Update.HTTPTimeoutSeconds = 300
 	}
+	if config.Update.MaxShrinkPercent > 100 {
+		config.Update.MaxShrinkPercent = 100
+	}
 	if config.Server.HTTPPort < 1024 || config.Server.HTTPPort > 65535 {
 		config.Server.HTTPPort = 8000
 	}
+	if config.Server.UpdateIntervalMinutes > 0 && config.Server.UpdateIntervalMinutes < minUpdateIntervalMinutes {
+		stdErr.Printf("Warning: Update interval raised to the minimum of %d minutes\n", minUpdateIntervalMinutes)
+		config.Server.UpdateIntervalMinutes = minUpdateIntervalMinutes
+	}
 	devMessage("Leaving sanitizeArguments()")
 }
 
@@ -159,68 +251,213 @@ func init() {
 func main() {
 	devMessage("Entering main()")
 
+	defaults, configFile, configErr := loadConfigFile(configFileFromArgs(os.Args[1:]))
+	if configErr != nil {
+		stdErr.Printf("Error loading config file: %s\n", configErr)
+		os.Exit(errConfigFile)
+	}
+
 	var rootCmd = &cobra.Command{Use: "ouilookup"}
 	rootCmd.Version = toolVersion
 	rootCmd.SetVersionTemplate(fmt.Sprintf("%s\n", toolID))
-	rootCmd.PersistentFlags().StringVarP(&config.DatabaseFile, "dbfile", "d", envordef.StringVal("OUILOOKUP_DBFILE", ouiDatabaseFile), "Local database file to use")
+	rootCmd.SetGlobalNormalizationFunc(normalizeFlagName)
+	rootCmd.PersistentFlags().StringVar(&config.ConfigFile, "config", configFile, "Config file to use (default: search user and system config directories)")
+	rootCmd.PersistentFlags().StringVarP(&config.DatabaseFile, "dbfile", "d", envordef.StringVal("OUILOOKUP_DBFILE", defaults.DatabaseFile), "Local database file to use (default: search user and system data directories)")
+	rootCmd.PersistentFlags().BoolVar(&config.NoCache, "nocache", envordef.BoolVal("OUILOOKUP_NOCACHE", defaults.NoCache), "Do not use or create a cache of the parsed database")
 
 	var cmdUpdate = &cobra.Command{
 		Use:   "update",
 		Short: "Update local OUI database",
-		Long:  `Use update to fetch a copy of an online OUI database and save it locally.`,
-		Args:  cobra.MinimumNArgs(0),
+		Long: `Use update to fetch a copy of an online OUI database and save it locally.
+By default the MA-L, MA-M, MA-S, IAB and CID registries are fetched and merged.
+The IEEE CSV files (e.g. oui.csv) can be used instead of the text files, but
+both formats must not be mixed. If the database file ends in ".bin", the
+database is stored in a binary format that allows instant lookups.`,
+		Args: cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			updateMain()
 		},
 	}
-	cmdUpdate.Flags().StringVarP(&config.Update.DatabaseURL, "dburl", "u", envordef.StringVal("OUILOOKUP_DBURL", ouiDatabaseURL), "URL to fetch the database from")
-	cmdUpdate.Flags().UintVarP(&config.Update.HTTPTimeoutSeconds, "httptimeout", "t", envordef.UintVal("OUILOOKUP_HTTPTIMEOUT", 60), "HTTP timeout in seconds")
+	cmdUpdate.Flags().StringSliceVarP(&config.Update.DatabaseURLs, "dburl", "u", strings.Split(envordef.StringVal("OUILOOKUP_DBURL", strings.Join(defaults.Update.DatabaseURLs, ",")), ","), "URLs to fetch the database from")
+	cmdUpdate.Flags().UintVarP(&config.Update.HTTPTimeoutSeconds, "httptimeout", "t", envordef.UintVal("OUILOOKUP_HTTPTIMEOUT", defaults.Update.HTTPTimeoutSeconds), "HTTP timeout in seconds")
+	cmdUpdate.Flags().UintVar(&config.Update.MaxShrinkPercent, "maxshrink", envordef.UintVal("OUILOOKUP_MAXSHRINK", defaults.Update.MaxShrinkPercent), "Reject databases with more than this percentage of entries fewer than the current one")
+	cmdUpdate.Flags().UintVar(&config.Update.HistorySize, "history", envordef.UintVal("OUILOOKUP_HISTORY", defaults.Update.HistorySize), "Number of database snapshots to keep, 0 to disable")
+	cmdUpdate.Flags().UintVar(&config.Update.HistoryDays, "historydays", envordef.UintVal("OUILOOKUP_HISTORYDAYS", defaults.Update.HistoryDays), "Days to keep database snapshots for --asof lookups beyond --history, 0 to disable")
+	cmdUpdate.Flags().BoolVar(&config.Update.Force, "force", false, "Replace the local database even if the new one is much smaller")
+	cmdUpdate.Flags().BoolVar(&config.Update.ShowDiff, "diff", false, "Show the changes against the previous local database")
+
+	var cmdImport = &cobra.Command{
+		Use:   "import file",
+		Short: "Import OUI database from a local file",
+		Long: `Use import to store a local copy of an OUI database, e.g. for air-gapped networks.
+The file may be gzip, xz or zstd compressed. Use "-" to read from stdin.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			importMain(args)
+		},
+	}
+	cmdImport.Flags().UintVar(&config.Update.MaxShrinkPercent, "maxshrink", envordef.UintVal("OUILOOKUP_MAXSHRINK", defaults.Update.MaxShrinkPercent), "Reject databases with more than this percentage of entries fewer than the current one")
+	cmdImport.Flags().UintVar(&config.Update.HistorySize, "history", envordef.UintVal("OUILOOKUP_HISTORY", defaults.Update.HistorySize), "Number of database snapshots to keep, 0 to disable")
+	cmdImport.Flags().UintVar(&config.Update.HistoryDays, "historydays", envordef.UintVal("OUILOOKUP_HISTORYDAYS", defaults.Update.HistoryDays), "Days to keep database snapshots for --asof lookups beyond --history, 0 to disable")
+	cmdImport.Flags().BoolVar(&config.Update.Force, "force", false, "Replace the local database even if the new one is much smaller")
 
 	var cmdExport = &cobra.Command{
 		Use:   "export",
 		Short: "Export OUI database",
 		Long: `Use export to export the locally stored OUI database in various formats.
-Valid output formats are "text", "csv" and "json". Output is written to stdout.`,
+Valid output formats are "text", "csv", "json", "manuf" (Wireshark),
+"nmap" (nmap-mac-prefixes), "arp-scan" (ieee-oui.txt), "arp-scan-vendor"
+(mac-vendor.txt) and "zeek" (input framework table). Output is written to stdout.`,
 		Args: cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			exportMain()
 		},
 	}
-	cmdExport.Flags().StringVarP(&config.Export.OutputFormat, "format", "f", envordef.StringVal("OUILOOKUP_EXPORTFORMAT", "csv"), "Output format for export")
+	cmdExport.Flags().StringVarP(&config.Export.OutputFormat, "format", "f", envordef.StringVal("OUILOOKUP_EXPORTFORMAT", defaults.Export.OutputFormat), "Output format for export")
 
 	var cmdMAC = &cobra.Command{
 		Use:   "mac [mac...]",
 		Short: "Look up MAC vendor",
-		Long:  `Use mac to retrieve the vendor name of any number of given MAC addresses.`,
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Use mac to retrieve the vendor name of any number of given MAC addresses.
+The most specific assignment is returned along with its registry and prefix length.
+MACs are read line by line from stdin if no MAC is given or if a MAC is "-".
+With --asof the MACs are looked up in the database snapshot that was current
+at the given date, or at the end of the given day; see history.`,
+		Args: cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			macMain(args)
 		},
 	}
+	cmdMAC.Flags().StringVarP(&config.MAC.InputFile, "input", "i", "", "File to read MACs from, one per line")
+	cmdMAC.Flags().StringVar(&config.MAC.AsOf, "asof", "", "Look up MACs in the database snapshot current at this time (RFC 3339) or at the end of this day (YYYY-MM-DD) (alias: --as-of)")
+	cmdMAC.Flags().StringVarP(&config.Output.Format, "output", "o", envordef.StringVal("OUILOOKUP_OUTPUTFORMAT", defaults.Output.Format), "Output format ("+outputFormats+")")
 
 	var cmdVendor = &cobra.Command{
 		Use:   "vendor [name...]",
 		Short: "Look up OUIs for a specific vendor",
-		Long:  `Use vendor to retrieve the OUIs assigned to a specific vendor.`,
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Use vendor to retrieve the OUIs assigned to a specific vendor.
+By default the vendor name has to match exactly. The match modes substring,
+prefix, regex and fuzzy ignore case and return all matching vendors, best
+matches first. With --group vendor names are compared after normalization
+(case, punctuation, legal forms like Inc or GmbH) and aliases, and all OUIs
+of the organizations found are returned.`,
+		Args: cobra.MinimumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			vendorMain(args)
 		},
 	}
+	cmdVendor.Flags().StringVarP(&config.Vendor.MatchMode, "match", "m", envordef.StringVal("OUILOOKUP_VENDORMATCH", defaults.Vendor.MatchMode), "Match mode (exact, substring, prefix, regex, fuzzy)")
+	cmdVendor.Flags().BoolVarP(&config.Vendor.Group, "group", "g", envordef.BoolVal("OUILOOKUP_VENDORGROUP", defaults.Vendor.Group), "Return the OUIs of all vendor names of the organizations found")
+	cmdVendor.Flags().StringVar(&config.Vendor.AliasesFile, "aliases", envordef.StringVal("OUILOOKUP_ALIASES", defaults.Vendor.AliasesFile), "File mapping organizations to their vendor names (default: search user and system config directories)")
+	cmdVendor.Flags().StringVarP(&config.Output.Format, "output", "o", envordef.StringVal("OUILOOKUP_OUTPUTFORMAT", defaults.Output.Format), "Output format ("+outputFormats+")")
+
+	var cmdInfo = &cobra.Command{
+		Use:   "info",
+		Short: "Show information about the local OUI database",
+		Long: `Use info to show the path, format, size, source, SHA-256 and statistics of
+the local OUI database. With --maxage the exit code is non-zero if the
+database is older than the given number of days.`,
+		Args: cobra.MinimumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			infoMain()
+		},
+	}
+	cmdInfo.Flags().UintVar(&config.Info.MaxAgeDays, "maxage", envordef.UintVal("OUILOOKUP_MAXAGE", defaults.Info.MaxAgeDays), "Maximum age of the database in days, 0 to disable the check")
+	cmdInfo.Flags().StringVar(&config.Vendor.AliasesFile, "aliases", envordef.StringVal("OUILOOKUP_ALIASES", defaults.Vendor.AliasesFile), "File mapping organizations to their vendor names (default: search user and system config directories)")
+	cmdInfo.Flags().StringVarP(&config.Output.ReportFormat, "output", "o", reportFormat(envordef.StringVal("OUILOOKUP_OUTPUTFORMAT", defaults.Output.Format)), "Output format (text, json, yaml)")
+
+	var cmdDiff = &cobra.Command{
+		Use:   "diff old new",
+		Short: "Show changes between two OUI databases",
+		Long: `Use diff to list the OUIs that were added, removed or changed (vendor name
+or address) between two database files.`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			diffMain(args)
+		},
+	}
+	cmdDiff.Flags().StringVarP(&config.Output.ReportFormat, "output", "o", reportFormat(envordef.StringVal("OUILOOKUP_OUTPUTFORMAT", defaults.Output.Format)), "Output format (text, json, yaml)")
+
+	var cmdHistory = &cobra.Command{
+		Use:   "history",
+		Short: "Manage database snapshots",
+		Long: `Use history to list and restore the snapshots of the local database that
+are taken after each update and import. The last --history snapshots are
+kept, and older ones as long as they are younger than --historydays days.`,
+	}
+
+	var cmdHistoryList = &cobra.Command{
+		Use:   "list",
+		Short: "List database snapshots",
+		Long:  `Use list to show the snapshots of the local database, oldest first.`,
+		Args:  cobra.MinimumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			historyListMain()
+		},
+	}
+	cmdHistoryList.Flags().StringVarP(&config.Output.ReportFormat, "output", "o", reportFormat(envordef.StringVal("OUILOOKUP_OUTPUTFORMAT", defaults.Output.Format)), "Output format (text, json, yaml)")
+
+	var cmdHistoryRollback = &cobra.Command{
+		Use:   "rollback id",
+		Short: "Restore a database snapshot",
+		Long: `Use rollback to replace the local database with the snapshot of the given ID.
+The restored database is recorded as a new snapshot.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			historyRollbackMain(args)
+		},
+	}
+	cmdHistoryRollback.Flags().UintVar(&config.Update.HistorySize, "history", envordef.UintVal("OUILOOKUP_HISTORY", defaults.Update.HistorySize), "Number of database snapshots to keep, 0 to disable")
+	cmdHistoryRollback.Flags().UintVar(&config.Update.HistoryDays, "historydays", envordef.UintVal("OUILOOKUP_HISTORYDAYS", defaults.Update.HistoryDays), "Days to keep database snapshots for --asof lookups beyond --history, 0 to disable")
+	cmdHistory.AddCommand(cmdHistoryList, cmdHistoryRollback)
 
 	var cmdServer = &cobra.Command{
 		Use:   "server",
 		Short: "Start a HTTP server to lookup MACs and vendors",
 		Long: `Use server to start a HTTP server. The server provides a RESTful API that
-can be used to lookup MACs and vendors.`,
+can be used to lookup MACs and vendors.
+The database is reloaded when the database file changes, on SIGHUP and on
+POST /admin/reload, which is only accepted from localhost unless --remoteadmin
+is given. With --updateinterval the local database is updated
+periodically.`,
 		Args: cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			serverMain()
 		},
 	}
-	cmdServer.Flags().UintVar(&config.Server.HTTPPort, "port", envordef.UintVal("OUILOOKUP_HTTP_PORT", 8000), "HTTP port to listen on")
+	cmdServer.Flags().StringVar(&config.Server.ListenAddress, "address", envordef.StringVal("OUILOOKUP_HTTP_ADDRESS", defaults.Server.ListenAddress), "Address to listen on, empty for all addresses")
+	cmdServer.Flags().UintVar(&config.Server.HTTPPort, "port", envordef.UintVal("OUILOOKUP_HTTP_PORT", defaults.Server.HTTPPort), "HTTP port to listen on")
+	cmdServer.Flags().UintVar(&config.Server.UpdateIntervalMinutes, "updateinterval", envordef.UintVal("OUILOOKUP_UPDATEINTERVAL", defaults.Server.UpdateIntervalMinutes), fmt.Sprintf("Minutes between updates of the local database, at least %d, 0 to disable (alias: --update-interval)", minUpdateIntervalMinutes))
+	cmdServer.Flags().StringSliceVarP(&config.Update.DatabaseURLs, "dburl", "u", strings.Split(envordef.StringVal("OUILOOKUP_DBURL", strings.Join(defaults.Update.DatabaseURLs, ",")), ","), "URLs to fetch the database from")
+	cmdServer.Flags().UintVarP(&config.Update.HTTPTimeoutSeconds, "httptimeout", "t", envordef.UintVal("OUILOOKUP_HTTPTIMEOUT", defaults.Update.HTTPTimeoutSeconds), "HTTP timeout in seconds")
+	cmdServer.Flags().UintVar(&config.Update.MaxShrinkPercent, "maxshrink", envordef.UintVal("OUILOOKUP_MAXSHRINK", defaults.Update.MaxShrinkPercent), "Reject databases with more than this percentage of entries fewer than the current one")
+	cmdServer.Flags().UintVar(&config.Update.HistorySize, "history", envordef.UintVal("OUILOOKUP_HISTORY", defaults.Update.HistorySize), "Number of database snapshots to keep, 0 to disable")
+	cmdServer.Flags().UintVar(&config.Update.HistoryDays, "historydays", envordef.UintVal("OUILOOKUP_HISTORYDAYS", defaults.Update.HistoryDays), "Days to keep database snapshots for --asof lookups beyond --history, 0 to disable")
+	cmdServer.Flags().StringVar(&config.Vendor.AliasesFile, "aliases", envordef.StringVal("OUILOOKUP_ALIASES", defaults.Vendor.AliasesFile), "File mapping organizations to their vendor names (default: search user and system config directories)")
+	cmdServer.Flags().BoolVar(&config.Server.RemoteAdmin, "remoteadmin", envordef.BoolVal("OUILOOKUP_REMOTEADMIN", defaults.Server.RemoteAdmin), "Allow POST /admin/reload from other hosts than localhost")
+	cmdServer.Flags().UintVar(&config.Server.WatchIntervalSeconds, "watchinterval", envordef.UintVal("OUILOOKUP_WATCHINTERVAL", defaults.Server.WatchIntervalSeconds), "Seconds between checks of the database file for changes, 0 to disable")
+
+	var cmdConfig = &cobra.Command{
+		Use:   "config",
+		Short: "Manage configuration",
+		Long: `Use config to inspect the configuration. Settings are taken from flags,
+environment variables, the config file and the defaults, in that order. The
+config file is given with --config or OUILOOKUP_CONFIG, otherwise
+$XDG_CONFIG_HOME/ouilookup/config.yaml and /etc/ouilookup/config.yaml are used.`,
+	}
+
+	var cmdConfigShow = &cobra.Command{
+		Use:   "show",
+		Short: "Show effective configuration",
+		Long:  `Use show to print the effective configuration in the config file format.`,
+		Args:  cobra.MinimumNArgs(0),
+		Run: func(cmd *cobra.Command, args []string) {
+			configShowMain()
+		},
+	}
+	cmdConfig.AddCommand(cmdConfigShow)
 
-	rootCmd.AddCommand(cmdUpdate, cmdExport, cmdMAC, cmdVendor, cmdServer)
+	rootCmd.AddCommand(cmdUpdate, cmdImport, cmdExport, cmdMAC, cmdVendor, cmdInfo, cmdDiff, cmdHistory, cmdServer, cmdConfig)
 	rootCmd.Execute()
 
 	devMessage("Leaving main()")
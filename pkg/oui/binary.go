@@ -0,0 +1,290 @@
+package oui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The binary format consists of a fixed size header, a sorted index of
+// fixed size records and a string table:
+//
+//	header   magic [8]byte, record count uint32, reserved uint32,
+//	         bit mask of prefix lengths uint64, string table offset uint32,
+//	         string table size uint32
+//	record   prefix left aligned in 48 bits uint64, prefix length uint8,
+//	         reserved [3]byte, string offsets of vendor name, vendor address,
+//	         registry and short name uint32 each
+//	strings  uvarint length followed by the bytes of the string
+//
+// All integers are little endian. Records are sorted by prefix and length,
+// so lookups are binary searches on the mapped file.
+
+const (
+	binaryHeaderSize int = 32
+	binaryRecordSize int = 28
+)
+
+var (
+	binaryMagic = []byte("OUIBIN\x00\x01")
+
+	// ErrNotBinary is returned when data is not in the binary database format.
+	ErrNotBinary = errors.New("not a binary OUI database")
+)
+
+// BinaryDatabase queries a database in binary format in place, without
+// parsing it first. Use OpenBinary to memory map a file or ReadBinary for
+// data that is already in memory.
+type BinaryDatabase struct {
+	data          []byte
+	count         int
+	prefixLengths []int
+	strings       []byte
+	closer        func() error
+}
+
+func isBinary(head []byte) bool {
+	return bytes.HasPrefix(head, binaryMagic)
+}
+
+// MarshalBinary encodes the database in the binary format.
+func (db *Database) MarshalBinary() ([]byte, error) {
+	var lengthMask uint64
+	var stringTable bytes.Buffer
+
+	offsets := make(map[string]uint32)
+	addString := func(s string) uint32 {
+		if offset, exists := offsets[s]; exists {
+			return offset
+		}
+		offset := uint32(stringTable.Len())
+		var length [binary.MaxVarintLen64]byte
+		stringTable.Write(length[:binary.PutUvarint(length[:], uint64(len(s)))])
+		stringTable.WriteString(s)
+		offsets[s] = offset
+		return offset
+	}
+	addString("")
+
+	prefixes := db.Prefixes()
+	records := make([]byte, len(prefixes)*binaryRecordSize)
+	sort.Slice(prefixes, func(i, j int) bool {
+		keyI, _ := prefixKey(prefixes[i])
+		keyJ, _ := prefixKey(prefixes[j])
+		if keyI != keyJ {
+			return keyI < keyJ
+		}
+		return len(prefixes[i]) < len(prefixes[j])
+	})
+	for i, prefix := range prefixes {
+		key, keyErr := prefixKey(prefix)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+		entry := db.entries[prefix]
+		record := records[i*binaryRecordSize : (i+1)*binaryRecordSize]
+		binary.LittleEndian.PutUint64(record[0:], key)
+		record[8] = byte(len(prefix) * 4)
+		binary.LittleEndian.PutUint32(record[12:], addString(entry.VendorName))
+		binary.LittleEndian.PutUint32(record[16:], addString(strings.Join(entry.VendorAddress, "\n")))
+		binary.LittleEndian.PutUint32(record[20:], addString(entry.Registry))
+		binary.LittleEndian.PutUint32(record[24:], addString(entry.ShortName))
+		lengthMask |= 1 << uint(len(prefix)*4)
+	}
+
+	header := make([]byte, binaryHeaderSize)
+	copy(header, binaryMagic)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(prefixes)))
+	binary.LittleEndian.PutUint64(header[16:], lengthMask)
+	binary.LittleEndian.PutUint32(header[24:], uint32(binaryHeaderSize+len(records)))
+	binary.LittleEndian.PutUint32(header[28:], uint32(stringTable.Len()))
+
+	return bytes.Join([][]byte{header, records, stringTable.Bytes()}, nil), nil
+}
+
+func prefixKey(prefix string) (uint64, error) {
+	if len(prefix) > 12 {
+		return 0, fmt.Errorf("Prefix %s too long", prefix)
+	}
+	key, keyErr := strconv.ParseUint(prefix+strings.Repeat("0", 12-len(prefix)), 16, 64)
+	if keyErr != nil {
+		return 0, fmt.Errorf("Invalid prefix %s: %s", prefix, keyErr)
+	}
+	return key, nil
+}
+
+// ReadBinary returns a BinaryDatabase that works on data directly.
+func ReadBinary(data []byte) (*BinaryDatabase, error) {
+	if len(data) < binaryHeaderSize || !isBinary(data) {
+		return nil, ErrNotBinary
+	}
+
+	bdb := &BinaryDatabase{data: data, count: int(binary.LittleEndian.Uint32(data[8:]))}
+	stringsOffset := int(binary.LittleEndian.Uint32(data[24:]))
+	stringsSize := int(binary.LittleEndian.Uint32(data[28:]))
+	if stringsOffset != binaryHeaderSize+bdb.count*binaryRecordSize || stringsOffset+stringsSize > len(data) {
+		return nil, fmt.Errorf("Binary OUI database is truncated")
+	}
+	bdb.strings = data[stringsOffset : stringsOffset+stringsSize]
+
+	// Prefixes are hex strings, so only multiples of 4 up to 48 bits are
+	// valid prefix lengths.
+	var validLengths uint64
+	for length := 4; length <= 48; length += 4 {
+		validLengths |= 1 << uint(length)
+	}
+	lengthMask := binary.LittleEndian.Uint64(data[16:])
+	if lengthMask&^validLengths != 0 {
+		return nil, fmt.Errorf("Binary OUI database has invalid prefix lengths")
+	}
+	for length := 48; length > 0; length-- {
+		if lengthMask&(1<<uint(length)) != 0 {
+			bdb.prefixLengths = append(bdb.prefixLengths, length)
+		}
+	}
+	for i := 0; i < bdb.count; i++ {
+		if lengthMask&(1<<uint(bdb.record(i)[8])) == 0 {
+			return nil, fmt.Errorf("Binary OUI database has an invalid prefix length in record %d", i)
+		}
+	}
+
+	return bdb, nil
+}
+
+// OpenBinary memory maps a database file in binary format. The returned
+// BinaryDatabase must be closed after use.
+func OpenBinary(fileName string) (*BinaryDatabase, error) {
+	fileHandle, fileErr := os.Open(fileName)
+	if fileErr != nil {
+		return nil, fileErr
+	}
+	defer fileHandle.Close()
+
+	data, closer, mapErr := mapFile(fileHandle)
+	if mapErr != nil {
+		return nil, mapErr
+	}
+	bdb, bdbErr := ReadBinary(data)
+	if bdbErr != nil {
+		closer()
+		return nil, bdbErr
+	}
+	bdb.closer = closer
+
+	return bdb, nil
+}
+
+// Close releases the memory mapping of the database.
+func (bdb *BinaryDatabase) Close() error {
+	if bdb.closer == nil {
+		return nil
+	}
+	closer := bdb.closer
+	bdb.closer = nil
+	bdb.data = nil
+	return closer()
+}
+
+// Len returns the number of assignments in the database.
+func (bdb *BinaryDatabase) Len() int {
+	return bdb.count
+}
+
+func (bdb *BinaryDatabase) record(i int) []byte {
+	offset := binaryHeaderSize + i*binaryRecordSize
+	return bdb.data[offset : offset+binaryRecordSize]
+}
+
+func (bdb *BinaryDatabase) string(offset uint32) string {
+	if int(offset) >= len(bdb.strings) {
+		return ""
+	}
+	length, n := binary.Uvarint(bdb.strings[offset:])
+	start := int(offset) + n
+	if n <= 0 || start+int(length) > len(bdb.strings) {
+		return ""
+	}
+	return string(bdb.strings[start : start+int(length)])
+}
+
+func (bdb *BinaryDatabase) entry(record []byte) Entry {
+	entry := Entry{
+		VendorName: bdb.string(binary.LittleEndian.Uint32(record[12:])),
+		Registry:   bdb.string(binary.LittleEndian.Uint32(record[20:])),
+		ShortName:  bdb.string(binary.LittleEndian.Uint32(record[24:])),
+	}
+	if address := bdb.string(binary.LittleEndian.Uint32(record[16:])); address != "" {
+		entry.VendorAddress = strings.Split(address, "\n")
+	}
+	return entry
+}
+
+// LookupMAC resolves a MAC address or OUI to its most specific assignment.
+// An unregistered address is not an error; Found is false in that case.
+func (bdb *BinaryDatabase) LookupMAC(mac string) (result Result, err error) {
+	result, hexOnly, err := newResult(mac)
+	if err != nil {
+		return
+	}
+
+	address, _ := prefixKey(hexOnly)
+	for _, prefixLength := range bdb.prefixLengths {
+		if len(hexOnly)*4 < prefixLength {
+			continue
+		}
+		key := address &^ (1<<uint(48-prefixLength) - 1)
+		i := sort.Search(bdb.count, func(i int) bool {
+			record := bdb.record(i)
+			recordKey := binary.LittleEndian.Uint64(record[0:])
+			return recordKey > key || (recordKey == key && int(record[8]) >= prefixLength)
+		})
+		if i >= bdb.count {
+			continue
+		}
+		record := bdb.record(i)
+		if binary.LittleEndian.Uint64(record[0:]) != key || int(record[8]) != prefixLength {
+			continue
+		}
+		result.Prefix = fmt.Sprintf("%012x", key)[:prefixLength/4]
+		result.PrefixLength = prefixLength
+		result.Found = true
+		result.Entry = bdb.entry(record)
+		break
+	}
+
+	return
+}
+
+// Database decodes all records into a Database.
+func (bdb *BinaryDatabase) Database() *Database {
+	entries := make(map[string]Entry, bdb.count)
+
+	for i := 0; i < bdb.count; i++ {
+		record := bdb.record(i)
+		prefixLength := int(record[8])
+		prefix := fmt.Sprintf("%012x", binary.LittleEndian.Uint64(record[0:]))[:prefixLength/4]
+		entries[prefix] = bdb.entry(record)
+	}
+
+	return NewDatabase(entries)
+}
+
+func parseBinary(r io.Reader) (*Database, error) {
+	var data bytes.Buffer
+
+	if _, readErr := data.ReadFrom(r); readErr != nil {
+		return nil, fmt.Errorf("Could not read binary database: %s", readErr)
+	}
+	bdb, bdbErr := ReadBinary(data.Bytes())
+	if bdbErr != nil {
+		return nil, bdbErr
+	}
+
+	return bdb.Database(), nil
+}
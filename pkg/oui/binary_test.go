@@ -0,0 +1,101 @@
+package oui
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	db := NewDatabase(map[string]Entry{
+		"00000c":    {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive", "San Jose  CA  94568", "US"}, Registry: RegistryMAL},
+		"0050c2":    {VendorName: "IEEE Registration Authority", VendorAddress: []string{"US"}, Registry: RegistryMAL},
+		"0050c2123": {VendorName: "Acme IAB", VendorAddress: []string{"Somewhere"}, Registry: RegistryIAB},
+		"0055da":    {VendorName: "IEEE Registration Authority", VendorAddress: []string{"US"}, Registry: RegistryMAL},
+		"0055da0":   {VendorName: "Shinko Technos co.,ltd.", VendorAddress: []string{"JP"}, Registry: RegistryMAM},
+		"70b3d5f8e": {VendorName: "Beta Labs", Registry: RegistryMAS, ShortName: "Beta"},
+	})
+
+	data, marshalErr := db.MarshalBinary()
+	if marshalErr != nil {
+		t.Fatalf("MarshalBinary() error = %s", marshalErr)
+	}
+	bdb, readErr := ReadBinary(data)
+	if readErr != nil {
+		t.Fatalf("ReadBinary() error = %s", readErr)
+	}
+	if bdb.Len() != db.Len() {
+		t.Fatalf("Len() = %d, want %d", bdb.Len(), db.Len())
+	}
+
+	macs := []string{
+		"00:00:0c:12:34:56", // /24
+		"00:55:da:01:02:03", // /28
+		"00:55:da:f1:02:03", // /24 below a /28
+		"00:50:c2:12:3a:bc", // /36
+		"00:50:c2:45:67:89", // /24 below a /36
+		"70:b3:d5:f8:e0:01", // /36 without /24
+		"70:b3:d5:00:00:01", // unregistered
+		"00-00-0C",
+		"not a mac",
+	}
+	for _, mac := range macs {
+		t.Run(mac, func(t *testing.T) {
+			want, wantErr := db.LookupMAC(mac)
+			got, gotErr := bdb.LookupMAC(mac)
+			if gotErr != wantErr {
+				t.Fatalf("BinaryDatabase.LookupMAC() error = %v, want %v", gotErr, wantErr)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("BinaryDatabase.LookupMAC() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	if decoded := bdb.Database(); !reflect.DeepEqual(decoded.Entries(), db.Entries()) {
+		t.Errorf("Database() = %#v, want %#v", decoded.Entries(), db.Entries())
+	}
+}
+
+func TestReadBinaryInvalid(t *testing.T) {
+	db := NewDatabase(map[string]Entry{
+		"00000c":    {VendorName: "Cisco Systems, Inc", Registry: RegistryMAL},
+		"0050c2123": {VendorName: "Acme IAB", Registry: RegistryIAB},
+	})
+	data, marshalErr := db.MarshalBinary()
+	if marshalErr != nil {
+		t.Fatalf("MarshalBinary() error = %s", marshalErr)
+	}
+
+	corrupt := func(modify func([]byte)) []byte {
+		modified := append([]byte(nil), data...)
+		modify(modified)
+		return modified
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"text", []byte("OUI/MA-L\t\t\tOrganization\n")},
+		{"header only", data[:binaryHeaderSize]},
+		{"truncated records", data[:binaryHeaderSize+binaryRecordSize]},
+		{"truncated strings", data[:len(data)-1]},
+		{"bad magic", corrupt(func(d []byte) { d[0] = 'X' })},
+		{"record count too high", corrupt(func(d []byte) { binary.LittleEndian.PutUint32(d[8:], 3) })},
+		{"record count overflow", corrupt(func(d []byte) { binary.LittleEndian.PutUint32(d[8:], 0xffffffff) })},
+		{"strings size too high", corrupt(func(d []byte) { binary.LittleEndian.PutUint32(d[28:], 0xffffffff) })},
+		{"prefix length too long", corrupt(func(d []byte) { d[binaryHeaderSize+8] = 52 })},
+		{"prefix length not in mask", corrupt(func(d []byte) { d[binaryHeaderSize+8] = 32 })},
+		{"prefix length mask too long", corrupt(func(d []byte) { binary.LittleEndian.PutUint64(d[16:], 1<<52) })},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, readErr := ReadBinary(tt.data); readErr == nil {
+				t.Errorf("ReadBinary() succeeded, want error")
+			}
+		})
+	}
+}
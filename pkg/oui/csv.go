@@ -0,0 +1,96 @@
+package oui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	reHexPrefix = regexp.MustCompile(`^[0-9a-f]{6,12}$`)
+
+	utf8BOM = []byte{0xef, 0xbb, 0xbf}
+)
+
+// isCSV reports whether the first line of head is a header row of the IEEE
+// registry CSV format.
+func isCSV(head []byte) bool {
+	head = bytes.TrimPrefix(head, utf8BOM)
+	if end := bytes.IndexByte(head, '\n'); end >= 0 {
+		head = head[:end]
+	}
+	record, recordErr := csv.NewReader(bytes.NewReader(head)).Read()
+	if recordErr != nil {
+		return false
+	}
+	return csvHeaderColumns(record) != nil
+}
+
+// ParseCSV reads one or more concatenated IEEE registry CSV files (oui.csv,
+// mam.csv, oui36.csv, iab.csv, cid.csv) and returns the resulting Database.
+// Columns are identified by the header row; repeated header rows are
+// skipped and malformed rows are ignored.
+func ParseCSV(r io.Reader) (*Database, error) {
+	var columns map[string]int
+
+	entries := make(map[string]Entry)
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	for {
+		record, recordErr := reader.Read()
+		if recordErr == io.EOF {
+			break
+		}
+		if recordErr != nil {
+			return nil, fmt.Errorf("Could not read CSV database: %s", recordErr)
+		}
+		if header := csvHeaderColumns(record); header != nil {
+			columns = header
+			continue
+		}
+
+		field := func(name string) string {
+			if i, exists := columns[name]; exists && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		prefix := strings.ToLower(field("assignment"))
+		if !reHexPrefix.MatchString(prefix) {
+			continue
+		}
+		entry := Entry{VendorName: field("organization name"), Registry: field("registry")}
+		if entry.Registry == "" {
+			entry.Registry = RegistryForPrefix(prefix)
+		}
+		if address := field("organization address"); address != "" {
+			entry.VendorAddress = []string{address}
+		}
+		entries[prefix] = entry
+	}
+
+	return NewDatabase(entries), nil
+}
+
+// csvHeaderColumns returns the column indexes by lower case column name if
+// record is a header row, i.e. has both a Registry and an Assignment column.
+// Otherwise nil is returned.
+func csvHeaderColumns(record []string) map[string]int {
+	columns := make(map[string]int)
+
+	for i, column := range record {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, string(utf8BOM))))] = i
+	}
+	_, hasRegistry := columns["registry"]
+	_, hasAssignment := columns["assignment"]
+	if !hasRegistry || !hasAssignment {
+		return nil
+	}
+
+	return columns
+}
@@ -0,0 +1,87 @@
+package oui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	const (
+		malCSV = "Registry,Assignment,Organization Name,Organization Address\n" +
+			"MA-L,00000C,\"Cisco Systems, Inc\",\"80 West Tasman Drive San Jose CA 94568 US\"\n"
+		mamCSV = "Registry,Assignment,Organization Name,Organization Address\n" +
+			"MA-M,0055DA0,\"Shinko Technos co.,ltd.\",Ogura 1-1-1 JP\n"
+	)
+
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]Entry
+	}{
+		{
+			name:  "single file",
+			input: malCSV,
+			want: map[string]Entry{
+				"00000c": {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive San Jose CA 94568 US"}, Registry: RegistryMAL},
+			},
+		},
+		{
+			name:  "byte order mark",
+			input: "\ufeff" + malCSV,
+			want: map[string]Entry{
+				"00000c": {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive San Jose CA 94568 US"}, Registry: RegistryMAL},
+			},
+		},
+		{
+			name:  "repeated headers",
+			input: malCSV + "\ufeff" + mamCSV,
+			want: map[string]Entry{
+				"00000c":  {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive San Jose CA 94568 US"}, Registry: RegistryMAL},
+				"0055da0": {VendorName: "Shinko Technos co.,ltd.", VendorAddress: []string{"Ogura 1-1-1 JP"}, Registry: RegistryMAM},
+			},
+		},
+		{
+			name: "repeated headers with different column order",
+			input: malCSV +
+				"Assignment,Registry,Organization Name\n" +
+				"0A1122,CID,Cid Corp\n",
+			want: map[string]Entry{
+				"00000c": {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive San Jose CA 94568 US"}, Registry: RegistryMAL},
+				"0a1122": {VendorName: "Cid Corp", Registry: RegistryCID},
+			},
+		},
+		{
+			name: "malformed rows",
+			input: malCSV +
+				"MA-L,XYZ,Broken Inc,Nowhere\n" +
+				"MA-L\n" +
+				"\n",
+			want: map[string]Entry{
+				"00000c": {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive San Jose CA 94568 US"}, Registry: RegistryMAL},
+			},
+		},
+		{
+			name:  "missing registry",
+			input: "Registry,Assignment,Organization Name,Organization Address\n,0050C2123,Acme IAB,\n",
+			want: map[string]Entry{
+				"0050c2123": {VendorName: "Acme IAB", Registry: RegistryIAB},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if format := DetectFormat([]byte(tt.input)); format != FormatCSV {
+				t.Fatalf("DetectFormat() = %s, want %s", format, FormatCSV)
+			}
+			db, parseErr := ParseCSV(strings.NewReader(tt.input))
+			if parseErr != nil {
+				t.Fatalf("ParseCSV() error = %s", parseErr)
+			}
+			if !reflect.DeepEqual(db.Entries(), tt.want) {
+				t.Errorf("ParseCSV() = %#v, want %#v", db.Entries(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package oui
+
+// Change describes a prefix whose assignment differs between two databases.
+// Old is nil for added prefixes, New is nil for removed prefixes.
+type Change struct {
+	Prefix string `json:"prefix"`
+	Old    *Entry `json:"old,omitempty"`
+	New    *Entry `json:"new,omitempty"`
+}
+
+// DatabaseDiff lists the prefixes that were added, removed or changed, each
+// sorted by prefix.
+type DatabaseDiff struct {
+	Added   []Change `json:"added"`
+	Removed []Change `json:"removed"`
+	Changed []Change `json:"changed"`
+}
+
+// Diff compares two databases. An assignment is considered changed if its
+// vendor name or vendor address differs.
+func Diff(oldDB *Database, newDB *Database) DatabaseDiff {
+	diff := DatabaseDiff{Added: []Change{}, Removed: []Change{}, Changed: []Change{}}
+
+	for _, prefix := range oldDB.Prefixes() {
+		oldEntry := oldDB.entries[prefix]
+		newEntry, found := newDB.entries[prefix]
+		if !found {
+			diff.Removed = append(diff.Removed, Change{Prefix: prefix, Old: &oldEntry})
+		} else if !sameAssignment(oldEntry, newEntry) {
+			diff.Changed = append(diff.Changed, Change{Prefix: prefix, Old: &oldEntry, New: &newEntry})
+		}
+	}
+	for _, prefix := range newDB.Prefixes() {
+		if _, found := oldDB.entries[prefix]; !found {
+			newEntry := newDB.entries[prefix]
+			diff.Added = append(diff.Added, Change{Prefix: prefix, New: &newEntry})
+		}
+	}
+
+	return diff
+}
+
+// Empty returns true if both databases contain the same assignments.
+func (diff DatabaseDiff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+func sameAssignment(a Entry, b Entry) bool {
+	if a.VendorName != b.VendorName || len(a.VendorAddress) != len(b.VendorAddress) {
+		return false
+	}
+	for i := range a.VendorAddress {
+		if a.VendorAddress[i] != b.VendorAddress[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package oui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ToText returns the database as tab separated prefix and vendor name lines.
+func (db *Database) ToText() string {
+	var lines []string
+
+	for oui, data := range db.entries {
+		lines = append(lines, fmt.Sprintf("%s\t%s", oui, data.VendorName))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ToCSV returns the database as quoted prefix and vendor name lines.
+func (db *Database) ToCSV() string {
+	var lines []string
+
+	for oui, data := range db.entries {
+		lines = append(lines, fmt.Sprintf(`"%s","%s"`, oui, data.VendorName))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ToJSON returns the database as indented JSON document.
+func (db *Database) ToJSON() string {
+	json, _ := json.MarshalIndent(db, "", "    ")
+	return string(json)
+}
+
+// ToNmap returns the database in the format of nmap's nmap-mac-prefixes.
+func (db *Database) ToNmap() string {
+	var lines []string
+
+	lines = append(lines, "# nmap-mac-prefixes", "# <prefix> <vendor name>")
+	for _, prefix := range db.Prefixes() {
+		lines = append(lines, fmt.Sprintf("%s %s", strings.ToUpper(prefix), singleLine(db.entries[prefix].VendorName)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ToArpScan returns the database in the format of arp-scan's ieee-oui.txt.
+func (db *Database) ToArpScan() string {
+	var lines []string
+
+	lines = append(lines, "# ieee-oui.txt", "# <prefix>\t<vendor name>")
+	for _, prefix := range db.Prefixes() {
+		lines = append(lines, fmt.Sprintf("%s\t%s", strings.ToUpper(prefix), singleLine(db.entries[prefix].VendorName)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ToArpScanVendor returns the database in the format of arp-scan's
+// mac-vendor.txt, which uses colon separated prefixes.
+func (db *Database) ToArpScanVendor() string {
+	var lines []string
+
+	lines = append(lines, "# mac-vendor.txt", "# <prefix>\t<vendor name>")
+	for _, prefix := range db.Prefixes() {
+		lines = append(lines, fmt.Sprintf("%s\t%s", colonSeparated(prefix), singleLine(db.entries[prefix].VendorName)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ToZeek returns the database as Zeek input framework table with the
+// columns prefix and vendor.
+func (db *Database) ToZeek() string {
+	var lines []string
+
+	lines = append(lines, "#separator \\x09", "#fields\tprefix\tvendor", "#types\tstring\tstring")
+	for _, prefix := range db.Prefixes() {
+		lines = append(lines, fmt.Sprintf("%s\t%s", colonSeparated(prefix), singleLine(db.entries[prefix].VendorName)))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func colonSeparated(prefix string) string {
+	var octets []string
+
+	for len(prefix) > 2 {
+		octets = append(octets, prefix[:2])
+		prefix = prefix[2:]
+	}
+	octets = append(octets, prefix)
+
+	return strings.Join(octets, ":")
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
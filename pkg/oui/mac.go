@@ -0,0 +1,89 @@
+package oui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	reFullMAC = regexp.MustCompile(`^([0-9A-Fa-f]{2}[-:]?){5}[0-9A-Fa-f]{2}$`)
+	reOUIOnly = regexp.MustCompile(`^([0-9A-Fa-f]{2}[-:]?){2}[0-9A-Fa-f]{2}$`)
+)
+
+func filterHexChars(r rune) rune {
+	switch {
+	case r >= '0' && r <= '9':
+		return r
+	case r >= 'A' && r <= 'F':
+		return r
+	case r >= 'a' && r <= 'f':
+		return r
+	}
+	return -1
+}
+
+// IsValidMAC reports whether mac is a full MAC address or an OUI, optionally
+// separated by colons or dashes.
+func IsValidMAC(mac string) bool {
+	return reFullMAC.MatchString(mac) || reOUIOnly.MatchString(mac)
+}
+
+// ExtractOUI returns the lower case 24 bit OUI of mac.
+func ExtractOUI(mac string) (oui string, err error) {
+	hexOnly := strings.ToLower(strings.Map(filterHexChars, mac))
+
+	if len(hexOnly) < 6 {
+		err = fmt.Errorf("Not enough characters to extract an OUI")
+		return
+	}
+	oui = hexOnly[:6]
+
+	return
+}
+
+// NormalizeMAC returns mac in lower case, colon separated notation. Missing
+// trailing digits are filled with zeroes.
+func NormalizeMAC(mac string) (normalizedMAC string, err error) {
+	var normalized []rune
+	var charCount uint
+
+	mac = strings.ToLower(strings.Map(filterHexChars, mac))
+	if len(mac) > 12 {
+		err = fmt.Errorf("MAC too long")
+		return
+	}
+	for len(mac) < 12 {
+		mac = mac + "0"
+	}
+
+	charCount = 0
+	for _, c := range mac {
+		charCount++
+		normalized = append(normalized, c)
+		if charCount%2 == 0 {
+			normalized = append(normalized, ':')
+		}
+	}
+	normalizedMAC = strings.Trim(string(normalized), ":")
+
+	return
+}
+
+// FormatPrefix returns a human readable notation of a hex prefix. 24 bit
+// prefixes are returned as "xx:xx:xx", longer ones as a full MAC address
+// followed by the prefix length, e.g. "00:50:c2:12:30:00/36".
+func FormatPrefix(prefix string) (formatted string, err error) {
+	mac, macErr := NormalizeMAC(prefix)
+	if macErr != nil {
+		err = macErr
+		return
+	}
+	if len(prefix) == 6 {
+		formatted = mac[:8]
+	} else {
+		formatted = fmt.Sprintf("%s/%d", mac, len(prefix)*4)
+	}
+
+	return
+}
@@ -0,0 +1,60 @@
+package oui
+
+import (
+	"testing"
+)
+
+func TestNormalizeMAC(t *testing.T) {
+	tests := []struct {
+		mac     string
+		want    string
+		wantErr bool
+	}{
+		{mac: "00:00:0C:12:34:56", want: "00:00:0c:12:34:56"},
+		{mac: "00-00-0c-12-34-56", want: "00:00:0c:12:34:56"},
+		{mac: "00000c123456", want: "00:00:0c:12:34:56"},
+		{mac: "0000.0c12.3456", want: "00:00:0c:12:34:56"},
+		{mac: "00:00:0c", want: "00:00:0c:00:00:00"},
+		{mac: "0050c2123", want: "00:50:c2:12:30:00"},
+		{mac: "", want: "00:00:00:00:00:00"},
+		{mac: "00:00:0c:12:34:56:78", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mac, func(t *testing.T) {
+			got, normalizeErr := NormalizeMAC(tt.mac)
+			if (normalizeErr != nil) != tt.wantErr {
+				t.Fatalf("NormalizeMAC() error = %v, wantErr %t", normalizeErr, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeMAC() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatPrefix(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{prefix: "00000c", want: "00:00:0c"},
+		{prefix: "0055da0", want: "00:55:da:00:00:00/28"},
+		{prefix: "0050c2123", want: "00:50:c2:12:30:00/36"},
+		{prefix: "70b3d5f8e", want: "70:b3:d5:f8:e0:00/36"},
+		{prefix: "00000c1234567", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got, formatErr := FormatPrefix(tt.prefix)
+			if (formatErr != nil) != tt.wantErr {
+				t.Fatalf("FormatPrefix() error = %v, wantErr %t", formatErr, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FormatPrefix() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package oui
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reManufLine = regexp.MustCompile(`^([0-9A-Fa-f]{2}[:\-.]){2}[0-9A-Fa-f]{2}\S*\s`)
+
+	// Terms that carry no information in a short name, following Wireshark's
+	// make-manuf.
+	reGeneralTerms = regexp.MustCompile(`(?i)\b(a ?\+ ?s|ab|ag|b ?v|co|company|corp|corporation|corporate|de c ?v|gmbh|holding|inc|incorporated|jsc|kg|k ?k|limited|llc|ltd|n ?v|oao|of|ooo|oy|oyj|plc|pty|pvt|s ?a ?r ?l|s ?a|s ?p ?a|sp ?k|s ?r ?l|systems|the|zao|z ?o ?o)\b`)
+	reNonAlnum     = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+	reParentheses  = regexp.MustCompile(`\([^)]*\)`)
+)
+
+func isManuf(head []byte) bool {
+	for _, line := range bytes.Split(head, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if bytes.Contains(line, []byte("(hex)")) || bytes.Contains(line, []byte("(base 16)")) {
+			// IEEE registry text files without their header line
+			return false
+		}
+		return reManufLine.Match(append(line, '\n'))
+	}
+	return false
+}
+
+// ShortName derives a Wireshark style short name of at most 8 characters
+// from a vendor name, e.g. "Cisco" from "Cisco Systems, Inc".
+func ShortName(vendorName string) string {
+	name := reParentheses.ReplaceAllString(vendorName, " ")
+	name = reNonAlnum.ReplaceAllString(name, " ")
+	if shortened := reGeneralTerms.ReplaceAllString(name, " "); strings.TrimSpace(shortened) != "" {
+		name = shortened
+	}
+
+	var short []rune
+	for _, word := range strings.Fields(name) {
+		runes := []rune(word)
+		if strings.ToUpper(word) == word && len(runes) > 3 {
+			runes = []rune(strings.Title(strings.ToLower(word)))
+		}
+		short = append(short, runes...)
+	}
+	if len(short) > 8 {
+		short = short[:8]
+	}
+
+	return string(short)
+}
+
+// ParseManuf reads a Wireshark manuf file and returns the resulting Database.
+// Prefixes with a length that is not a multiple of 4 bits are skipped.
+func ParseManuf(r io.Reader) (*Database, error) {
+	entries := make(map[string]Entry)
+
+	fs := bufio.NewScanner(r)
+	for fs.Scan() {
+		line := strings.TrimSpace(fs.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 2 {
+			fields = strings.SplitN(line, " ", 2)
+			if len(fields) < 2 {
+				continue
+			}
+		}
+
+		prefix, prefixErr := manufPrefix(strings.TrimSpace(fields[0]))
+		if prefixErr != nil {
+			continue
+		}
+		entry := Entry{ShortName: strings.TrimSpace(fields[1]), Registry: RegistryForPrefix(prefix)}
+		if len(fields) == 3 {
+			entry.VendorName = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(fields[2]), "#"))
+		}
+		if entry.VendorName == "" {
+			entry.VendorName = entry.ShortName
+		}
+		entries[prefix] = entry
+	}
+	if scanErr := fs.Err(); scanErr != nil {
+		return nil, fmt.Errorf("Could not read manuf database: %s", scanErr)
+	}
+
+	return NewDatabase(entries), nil
+}
+
+func manufPrefix(notation string) (prefix string, err error) {
+	address := notation
+	prefixLength := 0
+	if slash := strings.Index(notation, "/"); slash >= 0 {
+		address = notation[:slash]
+		prefixLength, err = strconv.Atoi(notation[slash+1:])
+		if err != nil {
+			return
+		}
+	}
+
+	hexOnly := strings.ToLower(strings.Map(filterHexChars, address))
+	if prefixLength == 0 {
+		prefixLength = len(hexOnly) * 4
+	}
+	if prefixLength < 24 || prefixLength > 48 || prefixLength%4 != 0 || len(hexOnly)*4 < prefixLength {
+		err = fmt.Errorf("Unsupported prefix %s", notation)
+		return
+	}
+	prefix = hexOnly[:prefixLength/4]
+
+	return
+}
+
+// ToManuf returns the database as Wireshark manuf file.
+func (db *Database) ToManuf() string {
+	var lines []string
+
+	lines = append(lines, "# Wireshark manuf file", "#", "# <prefix>\t<short name>\t<long name>", "")
+	for _, prefix := range db.Prefixes() {
+		entry := db.entries[prefix]
+		mac, _ := NormalizeMAC(prefix)
+		notation := strings.ToUpper(mac[:8])
+		if len(prefix) != 6 {
+			notation = fmt.Sprintf("%s/%d", strings.ToUpper(mac), len(prefix)*4)
+		}
+		shortName := entry.ShortName
+		if shortName == "" {
+			shortName = ShortName(entry.VendorName)
+		}
+		lines = append(lines, fmt.Sprintf("%s\t%s\t%s", notation, shortName, entry.VendorName))
+	}
+
+	return strings.Join(lines, "\n")
+}
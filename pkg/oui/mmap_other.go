@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package oui
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the whole file on platforms without memory mapping.
+func mapFile(fileHandle *os.File) ([]byte, func() error, error) {
+	data, readErr := io.ReadAll(fileHandle)
+	if readErr != nil {
+		return nil, nil, readErr
+	}
+
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package oui
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+func mapFile(fileHandle *os.File) ([]byte, func() error, error) {
+	info, infoErr := fileHandle.Stat()
+	if infoErr != nil {
+		return nil, nil, infoErr
+	}
+	if info.Size() == 0 {
+		return nil, nil, ErrNotBinary
+	}
+
+	data, mapErr := syscall.Mmap(int(fileHandle.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if mapErr != nil {
+		return nil, nil, fmt.Errorf("Could not map database: %s", mapErr)
+	}
+
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,71 @@
+package oui
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// Legal forms that are removed from the end of normalized vendor names.
+	// The names are lower case and reduced to letters, digits and single
+	// spaces at this point, so "Co.,Ltd." reads "co ltd".
+	reLegalSuffix = regexp.MustCompile(`(^| )(a ?s|ab|ag|b ?v|bhd|co|company|corp|corporate|corporation|gmbh|inc|incorporated|jsc|kg|k ?k|limited|llc|ltd|ltda|n ?v|oao|ooo|oy|oyj|plc|pte|pty|pvt|s ?a ?r ?l|s ?a ?s|s ?a|s ?a de c ?v|de c ?v|s ?p ?a|sdn|sp ?z ?o ?o|s ?r ?l|zao)$`)
+)
+
+// NormalizeVendorName returns the canonical form of a vendor name that is
+// shared by the different spellings of one company: lower case, without
+// punctuation, parenthesized remarks and trailing legal forms. "Apple, Inc.",
+// "Apple Inc" and "APPLE, INC." are all normalized to "apple".
+func NormalizeVendorName(name string) string {
+	normalized := reParentheses.ReplaceAllString(strings.ToLower(name), " ")
+	normalized = strings.Join(strings.Fields(reNonAlnum.ReplaceAllString(normalized, " ")), " ")
+	for {
+		shortened := strings.TrimSpace(reLegalSuffix.ReplaceAllString(normalized, ""))
+		if shortened == normalized || shortened == "" {
+			break
+		}
+		normalized = shortened
+	}
+	return normalized
+}
+
+// Aliases maps normalized vendor names to the normalized name of the
+// organization they belong to, e.g. after an acquisition.
+type Aliases map[string]string
+
+// NewAliases creates Aliases from organization names mapped to the vendor
+// names that belong to them.
+func NewAliases(organizations map[string][]string) Aliases {
+	aliases := make(Aliases)
+	for organization, vendorNames := range organizations {
+		for _, vendorName := range vendorNames {
+			aliases[NormalizeVendorName(vendorName)] = NormalizeVendorName(organization)
+		}
+	}
+	return aliases
+}
+
+// Organization returns the key of the organization a vendor name belongs to:
+// its normalized name, or the organization it is an alias of.
+func (aliases Aliases) Organization(vendorName string) string {
+	normalized := NormalizeVendorName(vendorName)
+	if organization, found := aliases[normalized]; found {
+		return organization
+	}
+	return normalized
+}
+
+// GroupVendors returns the sorted vendor names of the database grouped by
+// the key of their organization. Aliases may be nil.
+func (db *Database) GroupVendors(aliases Aliases) map[string][]string {
+	groups := make(map[string][]string)
+	for vendorName := range db.vendors {
+		organization := aliases.Organization(vendorName)
+		groups[organization] = append(groups[organization], vendorName)
+	}
+	for organization := range groups {
+		sort.Strings(groups[organization])
+	}
+	return groups
+}
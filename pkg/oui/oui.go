@@ -0,0 +1,235 @@
+// Package oui parses IEEE OUI registries and resolves MAC addresses to the
+// organizations they are assigned to.
+//
+// All registries published by the IEEE (MA-L, MA-M, MA-S, IAB and CID) are
+// supported. Lookups always return the most specific assignment that matches
+// a given MAC address.
+package oui
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+/*
+######## ##    ## ########  ########  ######
+   ##     ##  ##  ##     ## ##       ##    ##
+   ##      ####   ##     ## ##       ##
+   ##       ##    ########  ######    ######
+   ##       ##    ##        ##             ##
+   ##       ##    ##        ##       ##    ##
+   ##       ##    ##        ########  ######
+*/
+
+// Entry is a single assignment within one of the IEEE registries.
+type Entry struct {
+	VendorName    string   `json:"vendorName"`
+	VendorAddress []string `json:"vendorAddress"`
+	Registry      string   `json:"registry"`
+	ShortName     string   `json:"shortName,omitempty"`
+}
+
+// Database maps lower case hex prefixes to their assignments. Prefixes of
+// the IEEE registries are 6 (MA-L, CID), 7 (MA-M) or 9 (MA-S, IAB) hex digits
+// long; other sources like Wireshark manuf files may add up to 12 digits.
+//
+// A Database must be created with NewDatabase, Parse or Load. It cannot be
+// modified after creation and is safe for concurrent lookups.
+type Database struct {
+	entries       map[string]Entry
+	vendors       map[string][]string
+	prefixLengths []int
+}
+
+// jsonDatabase is the JSON representation of a Database.
+type jsonDatabase struct {
+	Entries map[string]Entry `json:"ouiDatabase"`
+}
+
+// Result describes the outcome of a MAC lookup.
+type Result struct {
+	MAC          string `json:"mac"`
+	OUI          string `json:"oui"`
+	Prefix       string `json:"prefix,omitempty"`
+	PrefixLength int    `json:"prefixLength,omitempty"`
+	Found        bool   `json:"found"`
+	Entry
+}
+
+// Resolver resolves MAC addresses to their most specific assignment. It is
+// implemented by Database and BinaryDatabase.
+type Resolver interface {
+	LookupMAC(mac string) (Result, error)
+}
+
+/*
+ ######   #######  ##    ##  ######  ########    ###    ##    ## ########  ######
+##    ## ##     ## ###   ## ##    ##    ##      ## ##   ###   ##    ##    ##    ##
+##       ##     ## ####  ## ##          ##     ##   ##  ####  ##    ##    ##
+##       ##     ## ## ## ##  ######     ##    ##     ## ## ## ##    ##     ######
+##       ##     ## ##  ####       ##    ##    ######### ##  ####    ##          ##
+##    ## ##     ## ##   ### ##    ##    ##    ##     ## ##   ###    ##    ##    ##
+ ######   #######  ##    ##  ######     ##    ##     ## ##    ##    ##     ######
+*/
+
+// Names of the IEEE registries.
+const (
+	RegistryMAL     string = "MA-L"
+	RegistryMAM     string = "MA-M"
+	RegistryMAS     string = "MA-S"
+	RegistryIAB     string = "IAB"
+	RegistryCID     string = "CID"
+	RegistryUnknown string = "unknown"
+)
+
+var (
+	// ErrInvalidMAC is returned for input that is neither a MAC address nor an OUI.
+	ErrInvalidMAC = errors.New("invalid MAC address")
+)
+
+/*
+######## ##     ## ##    ##  ######   ######
+##       ##     ## ###   ## ##    ## ##    ##
+##       ##     ## ####  ## ##       ##
+######   ##     ## ## ## ## ##        ######
+##       ##     ## ##  #### ##             ##
+##       ##     ## ##   ### ##    ## ##    ##
+##        #######  ##    ##  ######   ######
+*/
+
+// NewDatabase creates a Database from a copy of the given entries and builds
+// the indexes needed for lookups. Keys must be lower case hex prefixes.
+func NewDatabase(entries map[string]Entry) *Database {
+	db := &Database{entries: make(map[string]Entry, len(entries))}
+	for prefix, entry := range entries {
+		db.entries[prefix] = entry
+	}
+	db.buildIndex()
+	return db
+}
+
+func (db *Database) buildIndex() {
+	lengths := make(map[int]bool)
+
+	db.vendors = make(map[string][]string)
+	for prefix, entry := range db.entries {
+		db.vendors[entry.VendorName] = append(db.vendors[entry.VendorName], prefix)
+		lengths[len(prefix)] = true
+	}
+	for vendor := range db.vendors {
+		sort.Strings(db.vendors[vendor])
+	}
+
+	db.prefixLengths = nil
+	for length := range lengths {
+		db.prefixLengths = append(db.prefixLengths, length)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(db.prefixLengths)))
+}
+
+// Len returns the number of assignments in the database.
+func (db *Database) Len() int {
+	return len(db.entries)
+}
+
+// Entry returns the assignment of the given prefix. The prefix has to match
+// exactly; use LookupMAC to find the assignment a MAC address belongs to.
+func (db *Database) Entry(prefix string) (entry Entry, found bool) {
+	entry, found = db.entries[prefix]
+	return
+}
+
+// Entries returns a copy of all assignments, keyed by prefix.
+func (db *Database) Entries() map[string]Entry {
+	entries := make(map[string]Entry, len(db.entries))
+	for prefix, entry := range db.entries {
+		entries[prefix] = entry
+	}
+	return entries
+}
+
+// MarshalJSON encodes the database as a JSON object with the assignments
+// keyed by prefix in the member "ouiDatabase".
+func (db *Database) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDatabase{Entries: db.entries})
+}
+
+// UnmarshalJSON decodes a database encoded by MarshalJSON and builds the
+// indexes needed for lookups.
+func (db *Database) UnmarshalJSON(data []byte) error {
+	var decoded jsonDatabase
+	if decodeErr := json.Unmarshal(data, &decoded); decodeErr != nil {
+		return decodeErr
+	}
+	*db = *NewDatabase(decoded.Entries)
+	return nil
+}
+
+// Prefixes returns all prefixes of the database in sorted order.
+func (db *Database) Prefixes() []string {
+	prefixes := make([]string, 0, len(db.entries))
+	for prefix := range db.entries {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// Vendors returns all vendor names mapped to their sorted prefixes. The
+// returned map is shared and must not be modified.
+func (db *Database) Vendors() map[string][]string {
+	return db.vendors
+}
+
+// LookupVendor returns the sorted prefixes assigned to the vendor with the
+// exact given name, or nil if the vendor is unknown.
+func (db *Database) LookupVendor(name string) []string {
+	return db.vendors[name]
+}
+
+// LookupMAC resolves a MAC address or OUI to its most specific assignment.
+// An unregistered address is not an error; Found is false in that case.
+func (db *Database) LookupMAC(mac string) (result Result, err error) {
+	result, hexOnly, err := newResult(mac)
+	if err != nil {
+		return
+	}
+
+	for _, prefixLength := range db.prefixLengths {
+		if len(hexOnly) < prefixLength {
+			continue
+		}
+		if entry, found := db.entries[hexOnly[:prefixLength]]; found {
+			result.Prefix = hexOnly[:prefixLength]
+			result.PrefixLength = prefixLength * 4
+			result.Found = true
+			result.Entry = entry
+			break
+		}
+	}
+
+	return
+}
+
+// newResult validates mac and returns a Result with the address fields set,
+// along with the lower case hex digits of mac.
+func newResult(mac string) (result Result, hexOnly string, err error) {
+	if !IsValidMAC(mac) {
+		err = ErrInvalidMAC
+		return
+	}
+
+	result.MAC, err = NormalizeMAC(mac)
+	if err != nil {
+		return
+	}
+	result.OUI, err = ExtractOUI(mac)
+	if err != nil {
+		return
+	}
+	hexOnly = strings.ToLower(strings.Map(filterHexChars, mac))
+
+	return
+}
@@ -0,0 +1,108 @@
+package oui
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testDatabase() *Database {
+	return NewDatabase(map[string]Entry{
+		"00000c":    {VendorName: "Cisco Systems, Inc", Registry: RegistryMAL},
+		"0050c2":    {VendorName: "IEEE Registration Authority", Registry: RegistryMAL},
+		"0050c2123": {VendorName: "Acme IAB", Registry: RegistryIAB},
+		"0055da":    {VendorName: "IEEE Registration Authority", Registry: RegistryMAL},
+		"0055da0":   {VendorName: "Shinko Technos co.,ltd.", Registry: RegistryMAM},
+	})
+}
+
+func TestLookupMAC(t *testing.T) {
+	tests := []struct {
+		mac          string
+		wantErr      bool
+		wantFound    bool
+		wantMAC      string
+		wantPrefix   string
+		wantLength   int
+		wantVendor   string
+		wantRegistry string
+	}{
+		{mac: "00:00:0c:12:34:56", wantFound: true, wantMAC: "00:00:0c:12:34:56", wantPrefix: "00000c", wantLength: 24, wantVendor: "Cisco Systems, Inc", wantRegistry: RegistryMAL},
+		{mac: "00-00-0C", wantFound: true, wantMAC: "00:00:0c:00:00:00", wantPrefix: "00000c", wantLength: 24, wantVendor: "Cisco Systems, Inc", wantRegistry: RegistryMAL},
+		{mac: "0050c2123abc", wantFound: true, wantMAC: "00:50:c2:12:3a:bc", wantPrefix: "0050c2123", wantLength: 36, wantVendor: "Acme IAB", wantRegistry: RegistryIAB},
+		{mac: "00:50:c2:45:67:89", wantFound: true, wantMAC: "00:50:c2:45:67:89", wantPrefix: "0050c2", wantLength: 24, wantVendor: "IEEE Registration Authority", wantRegistry: RegistryMAL},
+		{mac: "00:55:da:01:02:03", wantFound: true, wantMAC: "00:55:da:01:02:03", wantPrefix: "0055da0", wantLength: 28, wantVendor: "Shinko Technos co.,ltd.", wantRegistry: RegistryMAM},
+		{mac: "00:55:da:f1:02:03", wantFound: true, wantMAC: "00:55:da:f1:02:03", wantPrefix: "0055da", wantLength: 24, wantVendor: "IEEE Registration Authority", wantRegistry: RegistryMAL},
+		{mac: "ff:ff:ff:ff:ff:ff", wantFound: false, wantMAC: "ff:ff:ff:ff:ff:ff"},
+		{mac: "00:00:0c:12", wantErr: true},
+		{mac: "not a mac", wantErr: true},
+	}
+
+	db := testDatabase()
+	for _, tt := range tests {
+		t.Run(tt.mac, func(t *testing.T) {
+			result, lookupErr := db.LookupMAC(tt.mac)
+			if tt.wantErr {
+				if lookupErr != ErrInvalidMAC {
+					t.Fatalf("LookupMAC() error = %v, want %v", lookupErr, ErrInvalidMAC)
+				}
+				return
+			}
+			if lookupErr != nil {
+				t.Fatalf("LookupMAC() error = %s", lookupErr)
+			}
+			if result.Found != tt.wantFound || result.MAC != tt.wantMAC || result.Prefix != tt.wantPrefix || result.PrefixLength != tt.wantLength {
+				t.Errorf("LookupMAC() = %+v, want found %t, MAC %s, prefix %s/%d", result, tt.wantFound, tt.wantMAC, tt.wantPrefix, tt.wantLength)
+			}
+			if result.VendorName != tt.wantVendor || result.Registry != tt.wantRegistry {
+				t.Errorf("LookupMAC() vendor = %s (%s), want %s (%s)", result.VendorName, result.Registry, tt.wantVendor, tt.wantRegistry)
+			}
+		})
+	}
+}
+
+func TestDatabaseIsImmutable(t *testing.T) {
+	entries := map[string]Entry{
+		"00000c": {VendorName: "Cisco Systems, Inc", Registry: RegistryMAL},
+	}
+	db := NewDatabase(entries)
+
+	entries["00000c"] = Entry{VendorName: "Changed"}
+	entries["0050c2123"] = Entry{VendorName: "Added"}
+	copied := db.Entries()
+	copied["00000c"] = Entry{VendorName: "Changed"}
+	delete(copied, "00000c")
+
+	if entry, found := db.Entry("00000c"); !found || entry.VendorName != "Cisco Systems, Inc" {
+		t.Errorf("Entry() = %+v, %t after modifying the maps", entry, found)
+	}
+	if _, found := db.Entry("0050c2123"); found || db.Len() != 1 {
+		t.Errorf("database has %d entries after modifying the maps, want 1", db.Len())
+	}
+	if result, _ := db.LookupMAC("00:00:0c:12:34:56"); result.VendorName != "Cisco Systems, Inc" {
+		t.Errorf("LookupMAC() = %+v after modifying the maps", result)
+	}
+}
+
+func TestDatabaseJSON(t *testing.T) {
+	db := testDatabase()
+
+	encoded, encodeErr := json.Marshal(db)
+	if encodeErr != nil {
+		t.Fatalf("MarshalJSON() error = %s", encodeErr)
+	}
+	if !strings.HasPrefix(string(encoded), `{"ouiDatabase":{`) {
+		t.Errorf("MarshalJSON() = %s, want member ouiDatabase", encoded)
+	}
+	var decoded Database
+	if decodeErr := json.Unmarshal(encoded, &decoded); decodeErr != nil {
+		t.Fatalf("UnmarshalJSON() error = %s", decodeErr)
+	}
+	if !reflect.DeepEqual(decoded.Entries(), db.Entries()) {
+		t.Errorf("UnmarshalJSON() = %#v, want %#v", decoded.Entries(), db.Entries())
+	}
+	if result, _ := decoded.LookupMAC("00:55:da:01:02:03"); result.PrefixLength != 28 {
+		t.Errorf("LookupMAC() after UnmarshalJSON() = %+v, want /28 match", result)
+	}
+}
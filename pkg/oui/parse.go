@@ -0,0 +1,248 @@
+package oui
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	zstd "github.com/klauspost/compress/zstd"
+	xz "github.com/ulikunitz/xz"
+)
+
+var (
+	reHex    = regexp.MustCompile(`^([0-9A-Fa-f]{2})-([0-9A-Fa-f]{2})-([0-9A-Fa-f]{2})\s+\(hex\)`)
+	reBase16 = regexp.MustCompile(`^(.+?)\s+\(base 16\)\s+(.+?)$`)
+	// Header lines of the registry text files, e.g. "OUI/MA-L" or "OUI-36/MA-S"
+	reRegistryHeader = regexp.MustCompile(`^(?:OUI(?:-\d+)?/)?(MA-L|MA-M|MA-S|IAB|CID)\b`)
+
+	gzipMagic = []byte{0x1f, 0x8b}
+	xzMagic   = []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+const (
+	// Amount of data inspected to detect the format of a database
+	sniffSize int = 64 * 1024
+
+	// Database formats as returned by DetectFormat
+	FormatBinary string = "binary"
+	FormatCSV    string = "csv"
+	FormatManuf  string = "manuf"
+	FormatText   string = "text"
+
+	// Compression methods as returned by DetectCompression
+	CompressionNone string = "none"
+	CompressionGzip string = "gzip"
+	CompressionXZ   string = "xz"
+	CompressionZstd string = "zstd"
+)
+
+// DetectFormat returns the format of an uncompressed database, judged by its
+// first 64 KiB.
+func DetectFormat(head []byte) string {
+	switch {
+	case isBinary(head):
+		return FormatBinary
+	case isCSV(head):
+		return FormatCSV
+	case isManuf(head):
+		return FormatManuf
+	}
+	return FormatText
+}
+
+// DetectCompression returns the compression method of a database, judged by
+// its magic bytes.
+func DetectCompression(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(head, xzMagic):
+		return CompressionXZ
+	case bytes.HasPrefix(head, zstdMagic):
+		return CompressionZstd
+	}
+	return CompressionNone
+}
+
+// RegistryForPrefix derives the registry of an assignment from its hex
+// prefix. This is only a guess for sources that do not state the registry;
+// e.g. legacy MA-L blocks with the locally administered bit set are reported
+// as CID.
+func RegistryForPrefix(prefix string) string {
+	switch len(prefix) {
+	case 6:
+		firstOctet, firstOctetErr := strconv.ParseUint(prefix[:2], 16, 8)
+		if firstOctetErr == nil && firstOctet&0x02 != 0 {
+			return RegistryCID
+		}
+		return RegistryMAL
+	case 7:
+		return RegistryMAM
+	case 9:
+		if strings.HasPrefix(prefix, "0050c2") || strings.HasPrefix(prefix, "40d855") {
+			return RegistryIAB
+		}
+		return RegistryMAS
+	}
+	return RegistryUnknown
+}
+
+func assignmentPrefix(baseOUI string, assignment string) (prefix string, err error) {
+	parts := strings.Split(strings.ToLower(assignment), "-")
+
+	switch len(parts) {
+	case 1:
+		if len(parts[0]) != 6 {
+			err = fmt.Errorf("Invalid assignment %s", assignment)
+			return
+		}
+		prefix = parts[0]
+	case 2:
+		if baseOUI == "" || len(parts[0]) != len(parts[1]) {
+			err = fmt.Errorf("Invalid assignment range %s", assignment)
+			return
+		}
+		commonLength := 0
+		for commonLength < len(parts[0]) && parts[0][commonLength] == parts[1][commonLength] {
+			commonLength++
+		}
+		prefix = baseOUI + parts[0][:commonLength]
+	default:
+		err = fmt.Errorf("Invalid assignment %s", assignment)
+	}
+
+	return
+}
+
+// Parse reads an uncompressed database and returns the resulting Database.
+// The format is detected from the content: IEEE registry CSV files are read
+// by ParseCSV, Wireshark manuf files by ParseManuf, databases in binary
+// format are decoded completely and everything else is read by ParseText.
+func Parse(r io.Reader) (*Database, error) {
+	br := bufio.NewReaderSize(r, sniffSize)
+
+	head, _ := br.Peek(sniffSize)
+	switch DetectFormat(head) {
+	case FormatBinary:
+		return parseBinary(br)
+	case FormatCSV:
+		return ParseCSV(br)
+	case FormatManuf:
+		return ParseManuf(br)
+	}
+
+	return ParseText(br)
+}
+
+// ParseText reads one or more concatenated IEEE registry text files (oui.txt,
+// mam.txt, oui36.txt, iab.txt, cid.txt) and returns the resulting Database.
+// The registry of an entry is taken from the header line of its file, e.g.
+// "OUI-28/MA-M"; entries before any header line get the registry derived by
+// RegistryForPrefix. Malformed entries are skipped.
+func ParseText(r io.Reader) (*Database, error) {
+	var inVendorBlock bool
+	var registry string
+	var vendorRegistry string
+	var baseOUI string
+	var vendorOUI string
+	var vendorName string
+	var vendorAddress []string
+
+	entries := make(map[string]Entry)
+
+	inVendorBlock = false
+	fs := bufio.NewScanner(r)
+	for fs.Scan() {
+		if header := reRegistryHeader.FindStringSubmatch(fs.Text()); header != nil {
+			if inVendorBlock {
+				entries[vendorOUI] = Entry{VendorName: vendorName, VendorAddress: vendorAddress, Registry: vendorRegistry}
+				inVendorBlock = false
+				vendorAddress = []string{}
+			}
+			registry = header[1]
+			continue
+		}
+		if inVendorBlock {
+			trimmed := strings.TrimSpace(fs.Text())
+			if trimmed == "" {
+				entries[vendorOUI] = Entry{VendorName: vendorName, VendorAddress: vendorAddress, Registry: vendorRegistry}
+				inVendorBlock = false
+				vendorAddress = []string{}
+				continue
+			}
+			vendorAddress = append(vendorAddress, trimmed)
+		} else if reHex.Match(fs.Bytes()) {
+			hexData := reHex.FindStringSubmatch(fs.Text())
+			baseOUI = strings.ToLower(hexData[1] + hexData[2] + hexData[3])
+		} else if reBase16.Match(fs.Bytes()) {
+			ouiData := reBase16.FindStringSubmatch(fs.Text())
+			prefix, prefixErr := assignmentPrefix(baseOUI, strings.TrimSpace(ouiData[1]))
+			if prefixErr != nil {
+				continue
+			}
+			inVendorBlock = true
+			vendorOUI = prefix
+			vendorName = ouiData[2]
+			vendorRegistry = registry
+			if vendorRegistry == "" {
+				vendorRegistry = RegistryForPrefix(prefix)
+			}
+		}
+	}
+	if scanErr := fs.Err(); scanErr != nil {
+		return nil, fmt.Errorf("Could not read database: %s", scanErr)
+	}
+	if inVendorBlock {
+		entries[vendorOUI] = Entry{VendorName: vendorName, VendorAddress: vendorAddress, Registry: vendorRegistry}
+	}
+
+	return NewDatabase(entries), nil
+}
+
+// Decompress detects gzip, xz and zstd compressed data by its magic bytes and
+// returns a reader for the uncompressed content. Uncompressed data is passed
+// through unchanged.
+func Decompress(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	magic, _ := br.Peek(len(xzMagic))
+	switch DetectCompression(magic) {
+	case CompressionGzip:
+		gzipReader, gzipReaderErr := gzip.NewReader(br)
+		if gzipReaderErr != nil {
+			return nil, fmt.Errorf("Could not read gzip compressed data: %s", gzipReaderErr)
+		}
+		return gzipReader, nil
+	case CompressionXZ:
+		xzReader, xzReaderErr := xz.NewReader(br)
+		if xzReaderErr != nil {
+			return nil, fmt.Errorf("Could not read xz compressed data: %s", xzReaderErr)
+		}
+		return io.NopCloser(xzReader), nil
+	case CompressionZstd:
+		zstdReader, zstdReaderErr := zstd.NewReader(br)
+		if zstdReaderErr != nil {
+			return nil, fmt.Errorf("Could not read zstd compressed data: %s", zstdReaderErr)
+		}
+		return zstdReader.IOReadCloser(), nil
+	}
+
+	return io.NopCloser(br), nil
+}
+
+// Load reads a database that may be compressed (see Decompress) and parses it.
+func Load(r io.Reader) (*Database, error) {
+	content, contentErr := Decompress(r)
+	if contentErr != nil {
+		return nil, contentErr
+	}
+	defer content.Close()
+
+	return Parse(content)
+}
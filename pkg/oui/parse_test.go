@@ -0,0 +1,146 @@
+package oui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testMAL = "OUI/MA-L\t\t\tOrganization\n" +
+		"company_id\t\t\tOrganization\n" +
+		"\t\t\t\tAddress\n" +
+		"\n" +
+		"00-00-0C   (hex)\t\tCisco Systems, Inc\n" +
+		"00000C     (base 16)\t\tCisco Systems, Inc\n" +
+		"\t\t\t\t80 West Tasman Drive\n" +
+		"\t\t\t\tSan Jose  CA  94568\n" +
+		"\t\t\t\tUS\n" +
+		"\n" +
+		"02-60-8C   (hex)\t\t3COM\n" +
+		"02608C     (base 16)\t\t3COM\n" +
+		"\t\t\t\tUS\n"
+	testMAM = "OUI-28/MA-M\t\tOrganization\n" +
+		"\n" +
+		"00-55-DA   (hex)\t\tShinko Technos co.,ltd.\n" +
+		"000000-0FFFFF     (base 16)\t\tShinko Technos co.,ltd.\n" +
+		"\t\t\t\tJP\n"
+	testMAS = "OUI-36/MA-S\t\tOrganization\n" +
+		"\n" +
+		"70-B3-D5   (hex)\t\tBeta Labs\n" +
+		"F8E000-F8EFFF     (base 16)\t\tBeta Labs\n" +
+		"\t\t\t\tElsewhere\n"
+	testIAB = "IAB\t\t\tOrganization\n" +
+		"\n" +
+		"00-50-C2   (hex)\t\tAcme IAB\n" +
+		"123000-123FFF     (base 16)\t\tAcme IAB\n" +
+		"\t\t\t\tSomewhere\n"
+	testCID = "CID\t\t\tOrganization\n" +
+		"\n" +
+		"0A-11-22   (hex)\t\tCid Corp\n" +
+		"0A1122     (base 16)\t\tCid Corp\n" +
+		"\t\t\t\tUS\n"
+)
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  map[string]Entry
+	}{
+		{
+			name:  "MA-L",
+			input: testMAL,
+			want: map[string]Entry{
+				"00000c": {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive", "San Jose  CA  94568", "US"}, Registry: RegistryMAL},
+				"02608c": {VendorName: "3COM", VendorAddress: []string{"US"}, Registry: RegistryMAL},
+			},
+		},
+		{
+			name:  "MA-M",
+			input: testMAM,
+			want: map[string]Entry{
+				"0055da0": {VendorName: "Shinko Technos co.,ltd.", VendorAddress: []string{"JP"}, Registry: RegistryMAM},
+			},
+		},
+		{
+			name:  "MA-S",
+			input: testMAS,
+			want: map[string]Entry{
+				"70b3d5f8e": {VendorName: "Beta Labs", VendorAddress: []string{"Elsewhere"}, Registry: RegistryMAS},
+			},
+		},
+		{
+			name:  "IAB",
+			input: testIAB,
+			want: map[string]Entry{
+				"0050c2123": {VendorName: "Acme IAB", VendorAddress: []string{"Somewhere"}, Registry: RegistryIAB},
+			},
+		},
+		{
+			name:  "CID",
+			input: testCID,
+			want: map[string]Entry{
+				"0a1122": {VendorName: "Cid Corp", VendorAddress: []string{"US"}, Registry: RegistryCID},
+			},
+		},
+		{
+			name:  "concatenated without blank line before header",
+			input: strings.TrimSuffix(testMAM, "\n") + "\n" + testCID,
+			want: map[string]Entry{
+				"0055da0": {VendorName: "Shinko Technos co.,ltd.", VendorAddress: []string{"JP"}, Registry: RegistryMAM},
+				"0a1122":  {VendorName: "Cid Corp", VendorAddress: []string{"US"}, Registry: RegistryCID},
+			},
+		},
+		{
+			name:  "without header",
+			input: testMAL[strings.Index(testMAL, "\n\n")+2:],
+			want: map[string]Entry{
+				"00000c": {VendorName: "Cisco Systems, Inc", VendorAddress: []string{"80 West Tasman Drive", "San Jose  CA  94568", "US"}, Registry: RegistryMAL},
+				"02608c": {VendorName: "3COM", VendorAddress: []string{"US"}, Registry: RegistryCID},
+			},
+		},
+		{
+			name:  "malformed assignment",
+			input: "00-00-0C   (hex)\t\tBroken\n00000C-0     (base 16)\t\tBroken\n\t\t\t\tUS\n",
+			want:  map[string]Entry{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, parseErr := ParseText(strings.NewReader(tt.input))
+			if parseErr != nil {
+				t.Fatalf("ParseText() error = %s", parseErr)
+			}
+			if !reflect.DeepEqual(db.Entries(), tt.want) {
+				t.Errorf("ParseText() = %#v, want %#v", db.Entries(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"IEEE text", testMAL, FormatText},
+		{"IEEE text without header", testMAL[strings.Index(testMAL, "\n\n")+2:], FormatText},
+		{"IEEE CSV", "Registry,Assignment,Organization Name,Organization Address\nMA-L,00000C,Cisco,US\n", FormatCSV},
+		{"IEEE CSV with byte order mark", "\ufeffRegistry,Assignment,Organization Name,Organization Address\r\nMA-L,00000C,Cisco,US\r\n", FormatCSV},
+		{"IEEE CSV with other column order", "Assignment,Organization Name,Registry\n00000C,Cisco,MA-L\n", FormatCSV},
+		{"IEEE CSV with quoted header", "\"Registry\",\"Assignment\",\"Organization Name\"\n", FormatCSV},
+		{"CSV without assignment column", "Registry,Organization Name\nMA-L,Cisco\n", FormatText},
+		{"manuf", "00:00:0C\tCisco\tCisco Systems, Inc\n", FormatManuf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat([]byte(tt.input)); got != tt.want {
+				t.Errorf("DetectFormat() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package oui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Match modes of SearchVendors
+const (
+	MatchExact     string = "exact"
+	MatchSubstring string = "substring"
+	MatchPrefix    string = "prefix"
+	MatchRegex     string = "regex"
+	MatchFuzzy     string = "fuzzy"
+)
+
+// VendorMatch is a vendor found by SearchVendors. Distance is the edit
+// distance of the query to the closest part of the vendor name and only set
+// in fuzzy mode.
+type VendorMatch struct {
+	VendorName string
+	Prefixes   []string
+	Distance   int
+}
+
+// SearchVendors returns the vendors whose names match the query, best
+// matches first. Except for exact mode, matching ignores case. In fuzzy mode
+// vendor names are matched if some part of them is within an edit distance
+// of a quarter of the query length to the query.
+func (db *Database) SearchVendors(query string, mode string) (matches []VendorMatch, err error) {
+	var re *regexp.Regexp
+
+	lowerQuery := strings.ToLower(query)
+	maxDistance := len([]rune(lowerQuery)) / 4
+
+	switch mode {
+	case MatchExact:
+		if prefixes := db.LookupVendor(query); len(prefixes) > 0 {
+			matches = append(matches, VendorMatch{VendorName: query, Prefixes: prefixes})
+		}
+		return
+	case MatchRegex:
+		re, err = regexp.Compile("(?i)" + query)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid regular expression %s: %s", query, err)
+		}
+	case MatchSubstring, MatchPrefix, MatchFuzzy:
+	default:
+		return nil, fmt.Errorf("Unknown match mode %s", mode)
+	}
+
+	for name, prefixes := range db.vendors {
+		lowerName := strings.ToLower(name)
+		match := VendorMatch{VendorName: name, Prefixes: prefixes}
+		switch mode {
+		case MatchSubstring:
+			if !strings.Contains(lowerName, lowerQuery) {
+				continue
+			}
+		case MatchPrefix:
+			if !strings.HasPrefix(lowerName, lowerQuery) {
+				continue
+			}
+		case MatchRegex:
+			if !re.MatchString(name) {
+				continue
+			}
+		case MatchFuzzy:
+			match.Distance = substringDistance(lowerQuery, lowerName)
+			if match.Distance > maxDistance {
+				continue
+			}
+		}
+		matches = append(matches, match)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		iName, jName := strings.ToLower(matches[i].VendorName), strings.ToLower(matches[j].VendorName)
+		if mode != MatchRegex {
+			// Prefer names starting with the query, then shorter names.
+			iPrefix, jPrefix := strings.HasPrefix(iName, lowerQuery), strings.HasPrefix(jName, lowerQuery)
+			if iPrefix != jPrefix {
+				return iPrefix
+			}
+			if len(iName) != len(jName) {
+				return len(iName) < len(jName)
+			}
+		}
+		return matches[i].VendorName < matches[j].VendorName
+	})
+
+	return
+}
+
+// substringDistance returns the smallest Levenshtein distance between the
+// query and any substring of text.
+func substringDistance(query string, text string) int {
+	q, t := []rune(query), []rune(text)
+
+	previous := make([]int, len(t)+1)
+	current := make([]int, len(t)+1)
+	for i := 1; i <= len(q); i++ {
+		current[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if q[i-1] == t[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+
+	distance := len(q)
+	for _, candidate := range previous {
+		if candidate < distance {
+			distance = candidate
+		}
+	}
+	return distance
+}
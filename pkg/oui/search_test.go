@@ -0,0 +1,78 @@
+package oui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubstringDistance(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		want  int
+	}{
+		{"cisco", "cisco systems, inc", 0},
+		{"systems", "cisco systems, inc", 0},
+		{"cisko", "cisco systems, inc", 1},
+		{"cicso", "cisco systems, inc", 2},
+		{"ciscoo", "cisco systems, inc", 1},
+		{"csco", "cisco systems, inc", 1},
+		{"juniper", "cisco systems, inc", 6},
+		{"", "cisco", 0},
+		{"cisco", "", 5},
+		{"münchen", "stadtwerke muenchen", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query+"/"+tt.text, func(t *testing.T) {
+			if got := substringDistance(tt.query, tt.text); got != tt.want {
+				t.Errorf("substringDistance(%q, %q) = %d, want %d", tt.query, tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchVendors(t *testing.T) {
+	db := NewDatabase(map[string]Entry{
+		"00000c": {VendorName: "Cisco Systems, Inc"},
+		"00000d": {VendorName: "Cisco"},
+		"00000e": {VendorName: "Sisco Networks"},
+		"00000f": {VendorName: "Cisco-Linksys, LLC"},
+		"000010": {VendorName: "Acme Cisko"},
+		"000011": {VendorName: "Juniper Networks"},
+	})
+
+	tests := []struct {
+		query   string
+		mode    string
+		want    []string
+		wantErr bool
+	}{
+		{query: "Cisco", mode: MatchExact, want: []string{"Cisco"}},
+		{query: "cisco", mode: MatchExact, want: nil},
+		{query: "cisco", mode: MatchPrefix, want: []string{"Cisco", "Cisco Systems, Inc", "Cisco-Linksys, LLC"}},
+		{query: "networks", mode: MatchSubstring, want: []string{"Sisco Networks", "Juniper Networks"}},
+		{query: "^(acme|juniper)", mode: MatchRegex, want: []string{"Acme Cisko", "Juniper Networks"}},
+		{query: "cisco", mode: MatchFuzzy, want: []string{"Cisco", "Cisco Systems, Inc", "Cisco-Linksys, LLC", "Acme Cisko", "Sisco Networks"}},
+		{query: "cisko", mode: MatchFuzzy, want: []string{"Acme Cisko", "Cisco", "Cisco Systems, Inc", "Cisco-Linksys, LLC"}},
+		{query: "jnuiper", mode: MatchFuzzy, want: nil},
+		{query: "(", mode: MatchRegex, wantErr: true},
+		{query: "cisco", mode: "unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.query, func(t *testing.T) {
+			matches, searchErr := db.SearchVendors(tt.query, tt.mode)
+			if (searchErr != nil) != tt.wantErr {
+				t.Fatalf("SearchVendors() error = %v, wantErr %t", searchErr, tt.wantErr)
+			}
+			var got []string
+			for _, match := range matches {
+				got = append(got, match.VendorName)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchVendors() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}